@@ -0,0 +1,147 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrHistoryNotRecorded = errors.New("transition was performed, but its history entry couldn't be recorded")
+
+type (
+	// HistoryEntry records a transition performed by an instance, including
+	// failed ones, or the compensation of a transition.
+	HistoryEntry struct {
+		// Time is when the transition started according to the clock of
+		// the workflow.
+		Time time.Time
+
+		PreviousState any
+
+		// NewState is the state the instance is in after the transition.
+		// If it failed, that is the state of its failure edge or the
+		// previous state.
+		NewState any
+
+		Input    []any
+		Duration time.Duration
+		Err      error
+		Epsilon  bool
+
+		// Compensation is set for entries of compensations, which are
+		// recorded with the input of the transition they compensate.
+		Compensation bool
+	}
+
+	// HistorySink receives every history entry of the instances of a
+	// workflow along with the id of the instance, which is empty if it
+	// wasn't created by a Manager.
+	HistorySink interface {
+		AppendHistory(id string, entry HistoryEntry) error
+	}
+
+	// historyLog keeps the most recent history entries of an instance in a
+	// ring buffer, or all of them if its capacity is less than zero.
+	historyLog struct {
+		entries []HistoryEntry
+		next    int
+	}
+)
+
+// SetHistoryCapacity sets how many history entries each instance keeps.
+// Once the capacity is reached, the oldest entry is dropped for each new
+// one. A capacity of less than zero keeps all entries, and zero, the
+// default, none.
+func (w *Workflow) SetHistoryCapacity(capacity int) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.historyCapacity = capacity
+}
+
+// SetHistorySink makes the instances of the workflow pass every history
+// entry to the sink, regardless of their history capacity. If the sink
+// fails, the input that caused the transition returns an error wrapping
+// ErrHistoryNotRecorded. Transitions started by timeouts and forks have no
+// caller to return it to, so the sink has to report its own failures for
+// those.
+func (w *Workflow) SetHistorySink(sink HistorySink) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.historySink = sink
+}
+
+// History returns the history entries the instance keeps, oldest first.
+func (w *WorkflowInstance) History() []HistoryEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.trail.list()
+}
+
+func (w *Workflow) recordsHistory() bool {
+	return w.historyCapacity != 0 || w.historySink != nil
+}
+
+// recordEntry completes the entry of a transition with the current state
+// and records it.
+func (w *WorkflowInstance) recordEntry(entry HistoryEntry) {
+	if !w.workflow.recordsHistory() {
+		return
+	}
+
+	entry.NewState = w.currentState
+	w.trail.add(entry, w.workflow.historyCapacity)
+
+	if w.workflow.historySink == nil {
+		return
+	}
+
+	if err := w.workflow.historySink.AppendHistory(w.id, entry); err != nil {
+		w.historyErrs = append(w.historyErrs, err)
+	}
+}
+
+// withHistoryErrors adds the errors of the history sink that occurred while
+// applying an input to the error it returned.
+func (w *WorkflowInstance) withHistoryErrors(err error) error {
+	if len(w.historyErrs) == 0 {
+		return err
+	}
+
+	historyErr := fmt.Errorf("%w: %w", ErrHistoryNotRecorded, errors.Join(w.historyErrs...))
+	w.historyErrs = nil
+
+	return errors.Join(err, historyErr)
+}
+
+func (l *historyLog) add(entry HistoryEntry, capacity int) {
+	switch {
+	case capacity == 0:
+		return
+	case l.next == 0 && (capacity < 0 || len(l.entries) < capacity):
+		l.entries = append(l.entries, entry)
+		return
+	case len(l.entries) == capacity:
+		l.entries[l.next] = entry
+		l.next = (l.next + 1) % capacity
+		return
+	}
+
+	// The capacity has changed, so unroll the ring buffer
+	entries := append(l.list(), entry)
+	if capacity > 0 && len(entries) > capacity {
+		entries = entries[len(entries)-capacity:]
+	}
+	l.entries, l.next = entries, 0
+}
+
+func (l *historyLog) list() []HistoryEntry {
+	if len(l.entries) == 0 {
+		return nil
+	}
+
+	return append(slices.Clone(l.entries[l.next:]), l.entries[:l.next]...)
+}
@@ -0,0 +1,248 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateArticleDraft     struct{}
+	stateArticleReview    struct{}
+	stateArticlePublished struct{}
+
+	inputSubmit  string
+	inputRetract int
+)
+
+var errRetractionInvalid = errors.New("retraction is invalid")
+
+func newArticleWorkflow(clock *ManualClock) *Workflow {
+	w := NewWorkflow()
+	w.SetClock(clock)
+	w.AddTransitions(
+		func(s stateArticleDraft, i inputSubmit) stateArticleReview {
+			clock.Sleep(time.Second)
+			return stateArticleReview{}
+		},
+		func(s stateArticleReview) stateArticlePublished { return stateArticlePublished{} },
+		func(s stateArticlePublished, i inputRetract) (stateArticleDraft, error) {
+			if i < 0 {
+				return stateArticleDraft{}, errRetractionInvalid
+			}
+			return stateArticleDraft{}, nil
+		},
+	)
+
+	return w
+}
+
+func TestWorkflowInstance_History(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entries := []HistoryEntry{
+		{
+			Time:          start,
+			PreviousState: stateArticleDraft{},
+			NewState:      stateArticleReview{},
+			Input:         []any{inputSubmit("alex")},
+			Duration:      time.Second,
+		},
+		{
+			Time:          start.Add(time.Second),
+			PreviousState: stateArticleReview{},
+			NewState:      stateArticlePublished{},
+			Epsilon:       true,
+		},
+		{
+			Time:          start.Add(time.Second),
+			PreviousState: stateArticlePublished{},
+			NewState:      stateArticlePublished{},
+			Input:         []any{inputRetract(-1)},
+			Err:           errRetractionInvalid,
+		},
+		{
+			Time:          start.Add(time.Second),
+			PreviousState: stateArticlePublished{},
+			NewState:      stateArticleDraft{},
+			Input:         []any{inputRetract(1)},
+		},
+	}
+
+	testcases := []struct {
+		name     string
+		capacity int
+		expected []HistoryEntry
+	}{
+		{
+			name:     "disabled",
+			capacity: 0,
+			expected: nil,
+		},
+		{
+			name:     "ring buffer",
+			capacity: 3,
+			expected: entries[1:],
+		},
+		{
+			name:     "unbounded",
+			capacity: -1,
+			expected: entries,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w := newArticleWorkflow(NewManualClock(start))
+			w.SetHistoryCapacity(tt.capacity)
+
+			instance := w.New(stateArticleDraft{})
+			require.NoError(t, instance.ContinueWith(inputSubmit("alex")))
+			require.ErrorIs(t, instance.ContinueWith(inputRetract(-1)), errRetractionInvalid)
+			require.ErrorIs(t, instance.ContinueWith(stateArticleDraft{}), ErrTransitionDoesNotExist)
+			require.NoError(t, instance.ContinueWith(inputRetract(1)))
+
+			require.Equal(t, tt.expected, instance.History())
+
+			restored := w.Restore("restored", instance.Snapshot())
+			require.Equal(t, tt.expected, restored.History())
+		})
+	}
+}
+
+func TestWorkflowInstance_History_failureEdge(t *testing.T) {
+	t.Parallel()
+
+	w := newArticleWorkflow(NewManualClock(time.Time{}))
+	w.SetHistoryCapacity(-1)
+	w.AddFailureEdges(OnError[error](stateArticlePublished{}, stateArticleReview{}))
+
+	instance := w.New(stateArticlePublished{})
+	require.NoError(t, instance.ContinueWith(inputRetract(-1)))
+
+	history := instance.History()
+	require.Len(t, history, 2)
+	require.Equal(t, stateArticleReview{}, history[0].NewState)
+	require.ErrorIs(t, history[0].Err, errRetractionInvalid)
+	require.Equal(t, stateArticlePublished{}, history[1].NewState)
+	require.True(t, history[1].Epsilon)
+}
+
+type failingHistorySink struct{}
+
+func (failingHistorySink) AppendHistory(id string, entry HistoryEntry) error {
+	return errors.New("disk full")
+}
+
+func TestWorkflow_SetHistorySink(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	w := newArticleWorkflow(NewManualClock(time.Time{}))
+	w.SetHistoryCapacity(1)
+	w.SetHistorySink(store)
+
+	manager := NewManager(w, store)
+	instance, err := manager.New("article", stateArticleDraft{})
+	require.NoError(t, err)
+	require.NoError(t, instance.ContinueWith(inputSubmit("alex")))
+	require.NoError(t, instance.ContinueWith(inputRetract(1)))
+
+	require.Len(t, instance.History(), 1)
+	require.Len(t, store.History("article"), 3)
+	require.Equal(t, instance.History(), store.History("article")[2:])
+	require.Empty(t, store.History("other"))
+
+	w = newArticleWorkflow(NewManualClock(time.Time{}))
+	w.SetHistorySink(failingHistorySink{})
+
+	instance = w.New(stateArticleDraft{})
+	err = instance.ContinueWith(inputSubmit("alex"))
+	require.ErrorIs(t, err, ErrHistoryNotRecorded)
+	require.EqualError(t, err, "transition was performed, but its history entry couldn't be recorded: disk full\ndisk full")
+	require.Equal(t, stateArticlePublished{}, instance.CurrentState())
+	require.Empty(t, instance.History())
+
+	_, err = instance.SendAndWait(inputRetract(-1)).Wait()
+	require.ErrorIs(t, err, errRetractionInvalid)
+	require.ErrorIs(t, err, ErrHistoryNotRecorded)
+}
+
+func TestHistoryLog(t *testing.T) {
+	t.Parallel()
+
+	entry := func(i int) HistoryEntry { return HistoryEntry{Input: []any{i}} }
+
+	var log historyLog
+	for i := 0; i < 5; i++ {
+		log.add(entry(i), 3)
+	}
+	require.Equal(t, []HistoryEntry{entry(2), entry(3), entry(4)}, log.list())
+
+	log.add(entry(5), 4)
+	log.add(entry(6), 4)
+	require.Equal(t, []HistoryEntry{entry(3), entry(4), entry(5), entry(6)}, log.list())
+
+	log.add(entry(7), 2)
+	require.Equal(t, []HistoryEntry{entry(6), entry(7)}, log.list())
+
+	log.add(entry(8), -1)
+	require.Equal(t, []HistoryEntry{entry(6), entry(7), entry(8)}, log.list())
+}
+
+func TestWorkflow_SetHistorySink_timeout(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	w := newPhoneWorkflow(clock)
+	w.SetHistorySink(failingHistorySink{})
+
+	instance := w.New(stateRinging{})
+	clock.Advance(30 * time.Second)
+	require.Equal(t, stateMissed{}, instance.CurrentState())
+
+	require.Equal(t, ErrTransitionDoesNotExist, instance.ContinueWith(inputAnswer{}))
+}
+
+func TestWorkflowInstance_History_compensation(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	w := newTripWorkflow(&log, true)
+	w.SetHistoryCapacity(-1)
+	w.SetCompensationPolicy(CompensateOnFailure)
+
+	instance := w.New(stateTripPlanned{})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+	require.Error(t, instance.ContinueWith(inputConfirm(false)))
+
+	type step struct {
+		previousState, newState any
+		failed, compensation    bool
+	}
+
+	var steps []step
+	for _, entry := range instance.History() {
+		steps = append(steps, step{entry.PreviousState, entry.NewState, entry.Err != nil, entry.Compensation})
+	}
+
+	require.Equal(t, []step{
+		{stateTripPlanned{}, stateFlightReserved{}, false, false},
+		{stateFlightReserved{}, stateHotelReserved{}, false, false},
+		{stateHotelReserved{}, stateCarReserved{}, false, false},
+		{stateCarReserved{}, stateCarReserved{}, true, false},
+		{stateCarReserved{}, stateHotelReserved{}, false, true},
+		{stateHotelReserved{}, stateHotelReserved{}, true, true},
+	}, steps)
+	require.Equal(t, []any{inputReserve{}}, instance.History()[4].Input)
+}
@@ -0,0 +1,151 @@
+package ekstatic
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// Clock is the source of time for timeouts, scheduled inputs and
+	// retries. It can be replaced with a ManualClock in tests.
+	Clock interface {
+		Now() time.Time
+		AfterFunc(d time.Duration, f func()) Timer
+		Sleep(d time.Duration)
+	}
+
+	Timer interface {
+		Stop() bool
+	}
+
+	systemClock struct{}
+
+	// ManualClock is a Clock that only moves when it is advanced explicitly.
+	ManualClock struct {
+		now    time.Time
+		timers []*manualTimer
+
+		mu sync.Mutex
+	}
+
+	manualTimer struct {
+		clock    *ManualClock
+		deadline time.Time
+		f        func()
+	}
+)
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &manualTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		f:        f,
+	}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by d and runs the functions of all timers
+// that became due on the way, in the order of their deadlines and on the
+// calling goroutine.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+
+		next := -1
+		for i, t := range c.timers {
+			if !t.deadline.After(target) && (next == -1 || t.deadline.Before(c.timers[next].deadline)) {
+				next = i
+			}
+		}
+
+		if next == -1 {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+
+		t := c.timers[next]
+		c.timers = slices.Delete(c.timers, next, next+1)
+		if t.deadline.After(c.now) {
+			c.now = t.deadline
+		}
+
+		c.mu.Unlock()
+
+		t.f()
+	}
+}
+
+// Sleep moves the clock forward by d without blocking. Timers that become due
+// aren't run until the clock is advanced the next time, since Sleep is
+// typically called while an instance is locked.
+func (c *ManualClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	i := slices.Index(t.clock.timers, t)
+	if i == -1 {
+		return false
+	}
+
+	t.clock.timers = slices.Delete(t.clock.timers, i, i+1)
+
+	return true
+}
+
+// SetClock replaces the clock used for the timeouts, scheduled inputs and
+// retries of the workflow.
+func (w *Workflow) SetClock(c Clock) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.clock = c
+}
+
+func (w *Workflow) getClock() Clock {
+	if w.clock == nil {
+		return systemClock{}
+	}
+
+	return w.clock
+}
@@ -0,0 +1,65 @@
+package ekstatic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testEpoch = time.Date(2024, time.April, 1, 12, 0, 0, 0, time.UTC)
+
+func TestManualClock_Advance(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+
+	var fired []string
+	clock.AfterFunc(3*time.Second, func() {
+		fired = append(fired, "third")
+		require.Equal(t, testEpoch.Add(3*time.Second), clock.Now())
+	})
+	clock.AfterFunc(time.Second, func() {
+		fired = append(fired, "first")
+		clock.AfterFunc(time.Second, func() { fired = append(fired, "second") })
+	})
+	stopped := clock.AfterFunc(2*time.Second, func() { fired = append(fired, "stopped") })
+	clock.AfterFunc(time.Minute, func() { fired = append(fired, "later") })
+
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+
+	clock.Advance(5 * time.Second)
+	require.Equal(t, []string{"first", "second", "third"}, fired)
+	require.Equal(t, testEpoch.Add(5*time.Second), clock.Now())
+
+	clock.Advance(time.Minute)
+	require.Equal(t, []string{"first", "second", "third", "later"}, fired)
+}
+
+func TestManualClock_Sleep(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+
+	fired := false
+	clock.AfterFunc(time.Second, func() { fired = true })
+
+	clock.Sleep(2 * time.Second)
+	require.Equal(t, testEpoch.Add(2*time.Second), clock.Now())
+	require.False(t, fired)
+
+	clock.Advance(0)
+	require.True(t, fired)
+}
+
+func TestWorkflow_SetClock(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	require.Equal(t, systemClock{}, w.getClock())
+
+	clock := NewManualClock(testEpoch)
+	w.SetClock(clock)
+	require.Same(t, clock, w.getClock())
+}
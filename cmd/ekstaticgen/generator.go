@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const ekstaticPath = "github.com/metamogul/ekstatic"
+
+var (
+	errTransitionsNotFound   = errors.New("transitions variable not found")
+	errTransitionsNotSlice   = errors.New("transitions variable must be initialized with a []ekstatic.Transition literal")
+	errTransitionNotFunc     = errors.New("transitions must be names of functions declared in the package")
+	errTransitionUnsupported = errors.New("transition signature isn't supported by the generator")
+	errTransitionDuplicate   = errors.New("there already is a transition for that state and input type")
+)
+
+type (
+	generator struct {
+		fset  *token.FileSet
+		files []*ast.File
+		funcs map[string]funcInFile
+		info  *types.Info
+
+		packageName string
+		imports     map[string]string
+	}
+
+	funcInFile struct {
+		decl *ast.FuncDecl
+		file *ast.File
+	}
+
+	transition struct {
+		name     string
+		state    string
+		inputs   []string
+		canFail  bool
+		position token.Position
+	}
+)
+
+// generate parses the non-test Go files in dir except output and returns the
+// source of a dispatcher named typeName for the transitions listed in the
+// variable named transitionsVar.
+func generate(dir, output, typeName, transitionsVar string) ([]byte, error) {
+	g := &generator{
+		fset:    token.NewFileSet(),
+		funcs:   make(map[string]funcInFile),
+		imports: make(map[string]string),
+	}
+
+	if err := g.parse(dir, output); err != nil {
+		return nil, err
+	}
+
+	names, err := g.transitionNames(transitionsVar)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := make([]transition, 0, len(names))
+	for _, name := range names {
+		t, err := g.transition(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, other := range transitions {
+			if other.state == t.state && slices.Equal(other.inputs, t.inputs) {
+				return nil, fmt.Errorf("%s: %w: %s", t.position, errTransitionDuplicate, other.name)
+			}
+		}
+
+		transitions = append(transitions, t)
+	}
+
+	return g.render(typeName, transitions)
+}
+
+func (g *generator) parse(dir, output string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == filepath.Base(output) {
+			continue
+		}
+
+		file, err := parser.ParseFile(g.fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+
+		g.files = append(g.files, file)
+		g.packageName = file.Name.Name
+
+		for _, decl := range file.Decls {
+			if funcDecl, isFunc := decl.(*ast.FuncDecl); isFunc && funcDecl.Recv == nil {
+				g.funcs[funcDecl.Name.Name] = funcInFile{funcDecl, file}
+			}
+		}
+	}
+
+	return nil
+}
+
+// typeOf returns the type of the expression, type-checking the package on
+// first use. Errors are ignored, since only the types of the parameters of
+// transitions matter.
+func (g *generator) typeOf(expr ast.Expr) types.Type {
+	if g.info != nil {
+		return g.info.TypeOf(expr)
+	}
+
+	g.info = &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+
+	config := types.Config{
+		Importer: importer.ForCompiler(g.fset, "source", nil),
+		Error:    func(error) {},
+	}
+	_, _ = config.Check(g.packageName, g.fset, g.files, g.info)
+
+	return g.info.TypeOf(expr)
+}
+
+func (g *generator) transitionNames(transitionsVar string) ([]string, error) {
+	for _, file := range g.files {
+		for _, decl := range file.Decls {
+			genDecl, isGen := decl.(*ast.GenDecl)
+			if !isGen || genDecl.Tok != token.VAR {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for i, name := range valueSpec.Names {
+					if name.Name != transitionsVar {
+						continue
+					}
+
+					if i >= len(valueSpec.Values) {
+						return nil, errTransitionsNotSlice
+					}
+
+					literal, isLiteral := valueSpec.Values[i].(*ast.CompositeLit)
+					if !isLiteral {
+						return nil, errTransitionsNotSlice
+					}
+
+					names := make([]string, len(literal.Elts))
+					for j, element := range literal.Elts {
+						ident, isIdent := element.(*ast.Ident)
+						if !isIdent {
+							return nil, fmt.Errorf("%s: %w", g.fset.Position(element.Pos()), errTransitionNotFunc)
+						}
+						names[j] = ident.Name
+					}
+
+					return names, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errTransitionsNotFound, transitionsVar)
+}
+
+func (g *generator) transition(name string) (transition, error) {
+	f, exists := g.funcs[name]
+	if !exists {
+		return transition{}, fmt.Errorf("%w: %s", errTransitionNotFunc, name)
+	}
+
+	t := transition{
+		name:     name,
+		position: g.fset.Position(f.decl.Pos()),
+	}
+
+	unsupported := func(reason string) (transition, error) {
+		return transition{}, fmt.Errorf("%s: %w: %s %s", t.position, errTransitionUnsupported, name, reason)
+	}
+
+	var (
+		params     []string
+		paramTypes []types.Type
+	)
+	for _, field := range f.decl.Type.Params.List {
+		if _, isVariadic := field.Type.(*ast.Ellipsis); isVariadic {
+			return unsupported("is variadic")
+		}
+
+		typeString, err := g.typeString(field.Type, f.file)
+		if err != nil {
+			return transition{}, err
+		}
+
+		for i := 0; i < max(1, len(field.Names)); i++ {
+			params = append(params, typeString)
+			paramTypes = append(paramTypes, g.typeOf(field.Type))
+		}
+	}
+
+	results := f.decl.Type.Results
+	resultCount := 0
+	if results != nil {
+		resultCount = results.NumFields()
+	}
+
+	switch {
+	case f.decl.Type.TypeParams != nil:
+		return unsupported("is generic")
+	case len(params) < 1:
+		return unsupported("accepts no state")
+	case resultCount < 1 || resultCount > 2:
+		return unsupported("must return a state and optionally an error")
+	case resultCount == 2 && !isIdent(results.List[len(results.List)-1].Type, "error"):
+		return unsupported("must return error as second value")
+	}
+
+	for i, param := range params {
+		// The type switch of the dispatcher would match every type
+		// implementing an interface, while a WorkflowInstance matches the
+		// exact type only and never performs such a transition
+		switch paramType := paramTypes[i]; {
+		case g.isEmitter(param):
+			return unsupported("accepts an Emitter")
+		case paramType == nil || paramType == types.Typ[types.Invalid]:
+			return unsupported("accepts " + param + ", whose type couldn't be determined")
+		case types.IsInterface(paramType):
+			return unsupported("accepts the interface type " + param)
+		}
+	}
+
+	t.state = params[0]
+	t.inputs = params[1:]
+	t.canFail = resultCount == 2
+
+	return t, nil
+}
+
+// typeString prints the type expression and records the imports it uses.
+func (g *generator) typeString(expr ast.Expr, file *ast.File) (string, error) {
+	var err error
+
+	ast.Inspect(expr, func(n ast.Node) bool {
+		selector, isSelector := n.(*ast.SelectorExpr)
+		if !isSelector {
+			return true
+		}
+
+		packageIdent, isIdent := selector.X.(*ast.Ident)
+		if !isIdent {
+			return true
+		}
+
+		path, found := importPath(file, packageIdent.Name)
+		if !found {
+			err = fmt.Errorf("%s: import of package %s not found", g.fset.Position(selector.Pos()), packageIdent.Name)
+			return false
+		}
+
+		g.imports[packageIdent.Name] = path
+		return false
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, g.fset, expr); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func (g *generator) isEmitter(typeString string) bool {
+	for name, path := range g.imports {
+		if path == ekstaticPath && typeString == name+".Emitter" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func importPath(file *ast.File, name string) (string, bool) {
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		importName := filepath.Base(path)
+		if spec.Name != nil {
+			importName = spec.Name.Name
+		}
+
+		if importName == name {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+func isStandard(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, isIdent := expr.(*ast.Ident)
+	return isIdent && ident.Name == name
+}
+
+func (g *generator) render(typeName string, transitions []transition) ([]byte, error) {
+	var (
+		b      bytes.Buffer
+		states []string
+		byType = make(map[string][]transition)
+	)
+
+	for _, t := range transitions {
+		if _, exists := byType[t.state]; !exists {
+			states = append(states, t.state)
+		}
+		byType[t.state] = append(byType[t.state], t)
+	}
+
+	imports := map[string]string{"sync": "sync", "ekstatic": ekstaticPath}
+	for name, path := range g.imports {
+		imports[name] = path
+	}
+
+	importNames := make([]string, 0, len(imports))
+	for name := range imports {
+		importNames = append(importNames, name)
+	}
+	slices.SortFunc(importNames, func(a, b string) int { return strings.Compare(imports[a], imports[b]) })
+	slices.SortStableFunc(importNames, func(a, b string) int {
+		return compareBool(isStandard(imports[b]), isStandard(imports[a]))
+	})
+
+	fmt.Fprintf(&b, "// Code generated by ekstaticgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", g.packageName)
+
+	b.WriteString("import (\n")
+	for i, name := range importNames {
+		if i > 0 && isStandard(imports[importNames[i-1]]) && !isStandard(imports[name]) {
+			b.WriteString("\n")
+		}
+		if filepath.Base(imports[name]) == name {
+			fmt.Fprintf(&b, "%q\n", imports[name])
+		} else {
+			fmt.Fprintf(&b, "%s %q\n", name, imports[name])
+		}
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, "// %s performs the transitions of the workflow without reflection.\n", typeName)
+	fmt.Fprintf(&b, "type %s struct {\n\tcurrentState any\n\n\tmu sync.Mutex\n}\n\n", typeName)
+	fmt.Fprintf(&b, "var _ ekstatic.Instance = (*%s)(nil)\n\n", typeName)
+
+	fmt.Fprintf(&b, "func New%s(initialState any) *%s {\n", typeName, typeName)
+	b.WriteString("if initialState == nil {\npanic(\"initial state must not be nil\")\n}\n\n")
+	fmt.Fprintf(&b, "return &%s{currentState: initialState}\n}\n\n", typeName)
+
+	fmt.Fprintf(&b, "func (w *%s) ContinueWith(input ...any) error {\n", typeName)
+	b.WriteString("w.mu.Lock()\ndefer w.mu.Unlock()\n\nreturn w.continueWith(input...)\n}\n\n")
+
+	fmt.Fprintf(&b, "func (w *%s) CurrentState() any {\n", typeName)
+	b.WriteString("w.mu.Lock()\ndefer w.mu.Unlock()\n\nreturn w.currentState\n}\n\n")
+
+	fmt.Fprintf(&b, "func (w *%s) continueWith(input ...any) error {\n", typeName)
+	b.WriteString("switch state := w.currentState.(type) {\n")
+	for _, state := range states {
+		fmt.Fprintf(&b, "case %s:\n", state)
+		for _, t := range byType[state] {
+			renderTransition(&b, t)
+		}
+	}
+	b.WriteString("}\n\nreturn ekstatic.ErrTransitionDoesNotExist\n}\n\n")
+
+	fmt.Fprintf(&b, "func (w *%s) enter(state any) error {\n", typeName)
+	b.WriteString("if state == nil {\npanic(\"transition returned nil as result state\")\n}\n\n")
+	b.WriteString("w.currentState = state\n\n")
+	b.WriteString("switch state.(type) {\n")
+	var epsilonStates []string
+	for _, t := range transitions {
+		if len(t.inputs) == 0 {
+			epsilonStates = append(epsilonStates, t.state)
+		}
+	}
+	if len(epsilonStates) > 0 {
+		fmt.Fprintf(&b, "case %s:\nreturn w.continueWith()\n", strings.Join(epsilonStates, ", "))
+	}
+	b.WriteString("}\n\nreturn nil\n}\n")
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return source, nil
+}
+
+func renderTransition(b *bytes.Buffer, t transition) {
+	fmt.Fprintf(b, "if len(input) == %d", len(t.inputs))
+
+	args := []string{"state"}
+	for i, input := range t.inputs {
+		fmt.Fprintf(b, " {\nif input%d, ok := input[%d].(%s); ok", i, i, input)
+		args = append(args, fmt.Sprintf("input%d", i))
+	}
+	b.WriteString(" {\n")
+
+	call := fmt.Sprintf("%s(%s)", t.name, strings.Join(args, ", "))
+	if t.canFail {
+		fmt.Fprintf(b, "nextState, err := %s\nif err != nil {\nreturn err\n}\nreturn w.enter(nextState)\n", call)
+	} else {
+		fmt.Fprintf(b, "return w.enter(%s)\n", call)
+	}
+
+	b.WriteString(strings.Repeat("}\n", len(t.inputs)+1))
+}
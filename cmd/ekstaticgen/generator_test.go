@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("internal", "turnstile")
+
+	want, err := os.ReadFile(filepath.Join(dir, "dispatcher_ekstatic.go"))
+	require.NoError(t, err)
+
+	source, err := generate(dir, "dispatcher_ekstatic.go", "Dispatcher", "transitions")
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(source), "generated code is outdated, run go generate")
+}
+
+func TestGenerate_errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name   string
+		source string
+		err    error
+	}{
+		{
+			name:   "variable not found",
+			source: `var other = []ekstatic.Transition{}`,
+			err:    errTransitionsNotFound,
+		},
+		{
+			name:   "variable isn't a literal",
+			source: `var transitions = other()`,
+			err:    errTransitionsNotSlice,
+		},
+		{
+			name:   "transition is a func literal",
+			source: `var transitions = []ekstatic.Transition{func(s string) string { return s }}`,
+			err:    errTransitionNotFunc,
+		},
+		{
+			name:   "transition isn't declared",
+			source: `var transitions = []ekstatic.Transition{missing}`,
+			err:    errTransitionNotFunc,
+		},
+		{
+			name: "transition is variadic",
+			source: `var transitions = []ekstatic.Transition{t}
+func t(s string, i ...int) string { return s }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "transition has no state",
+			source: `var transitions = []ekstatic.Transition{t}
+func t() string { return "" }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "transition has bad error output",
+			source: `var transitions = []ekstatic.Transition{t}
+func t(s string) (string, bool) { return s, true }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "transition accepts emitter",
+			source: `var transitions = []ekstatic.Transition{t}
+func t(s string, e ekstatic.Emitter) string { return s }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "transition accepts interface",
+			source: `var transitions = []ekstatic.Transition{t}
+type shape interface{ area() float64 }
+func t(s string, i shape) string { return s }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "transition accepts predeclared interface",
+			source: `var transitions = []ekstatic.Transition{t}
+func t(s error, i int) string { return "" }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "transition accepts unknown type",
+			source: `var transitions = []ekstatic.Transition{t}
+func t(s string, i missing) string { return s }`,
+			err: errTransitionUnsupported,
+		},
+		{
+			name: "duplicate transition",
+			source: `var transitions = []ekstatic.Transition{t, u}
+func t(s string, i int) string { return s }
+func u(a string, b int) (string, error) { return a, nil }`,
+			err: errTransitionDuplicate,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			dir := t.TempDir()
+			source := "package p\n\nimport \"github.com/metamogul/ekstatic\"\n\n" + tt.source + "\n"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "p.go"), []byte(source), 0o644))
+
+			_, err := generate(dir, "out.go", "Dispatcher", "transitions")
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Code generated by ekstaticgen. DO NOT EDIT.
+
+package turnstile
+
+import (
+	"sync"
+
+	"github.com/metamogul/ekstatic"
+)
+
+// Dispatcher performs the transitions of the workflow without reflection.
+type Dispatcher struct {
+	currentState any
+
+	mu sync.Mutex
+}
+
+var _ ekstatic.Instance = (*Dispatcher)(nil)
+
+func NewDispatcher(initialState any) *Dispatcher {
+	if initialState == nil {
+		panic("initial state must not be nil")
+	}
+
+	return &Dispatcher{currentState: initialState}
+}
+
+func (w *Dispatcher) ContinueWith(input ...any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.continueWith(input...)
+}
+
+func (w *Dispatcher) CurrentState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.currentState
+}
+
+func (w *Dispatcher) continueWith(input ...any) error {
+	switch state := w.currentState.(type) {
+	case Locked:
+		if len(input) == 1 {
+			if input0, ok := input[0].(Coin); ok {
+				nextState, err := insertCoin(state, input0)
+				if err != nil {
+					return err
+				}
+				return w.enter(nextState)
+			}
+		}
+		if len(input) == 1 {
+			if input0, ok := input[0].(Push); ok {
+				return w.enter(pushLocked(state, input0))
+			}
+		}
+		if len(input) == 2 {
+			if input0, ok := input[0].(Kick); ok {
+				if input1, ok := input[1].(Push); ok {
+					return w.enter(kick(state, input0, input1))
+				}
+			}
+		}
+	case Unlocked:
+		if len(input) == 1 {
+			if input0, ok := input[0].(Push); ok {
+				return w.enter(pushUnlocked(state, input0))
+			}
+		}
+	case Broken:
+		if len(input) == 0 {
+			return w.enter(repair(state))
+		}
+	}
+
+	return ekstatic.ErrTransitionDoesNotExist
+}
+
+func (w *Dispatcher) enter(state any) error {
+	if state == nil {
+		panic("transition returned nil as result state")
+	}
+
+	w.currentState = state
+
+	switch state.(type) {
+	case Broken:
+		return w.continueWith()
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// Package turnstile is a workflow used to test ekstaticgen and to compare
+// the generated dispatcher with a reflective WorkflowInstance.
+package turnstile
+
+import (
+	"errors"
+
+	"github.com/metamogul/ekstatic"
+)
+
+//go:generate go run ../.. -type Dispatcher -transitions transitions
+
+type (
+	Locked   struct{ Coins int }
+	Unlocked struct{ Coins int }
+	Broken   struct{}
+
+	Coin int
+	Push struct{}
+	Kick struct{}
+)
+
+var ErrCounterfeit = errors.New("counterfeit coin")
+
+var transitions = []ekstatic.Transition{
+	insertCoin,
+	pushLocked,
+	pushUnlocked,
+	kick,
+	repair,
+}
+
+func insertCoin(s Locked, c Coin) (Unlocked, error) {
+	if c <= 0 {
+		return Unlocked{}, ErrCounterfeit
+	}
+	return Unlocked{s.Coins + int(c)}, nil
+}
+
+func pushLocked(s Locked, p Push) Locked {
+	return s
+}
+
+func pushUnlocked(s Unlocked, p Push) Locked {
+	return Locked{s.Coins}
+}
+
+func kick(s Locked, k Kick, p Push) Broken {
+	return Broken{}
+}
+
+func repair(s Broken) Locked {
+	return Locked{}
+}
+
+// NewWorkflow returns a workflow with the same transitions as Dispatcher.
+func NewWorkflow() *ekstatic.Workflow {
+	w := ekstatic.NewWorkflow()
+	w.AddTransitions(transitions...)
+
+	return w
+}
@@ -0,0 +1,83 @@
+package turnstile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/metamogul/ekstatic"
+)
+
+func TestDispatcher(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		inputs           [][]any
+		err              error
+		destinationState any
+	}{
+		{
+			name:             "transition",
+			inputs:           [][]any{{Coin(2)}},
+			destinationState: Unlocked{2},
+		},
+		{
+			name:             "failing transition",
+			inputs:           [][]any{{Coin(0)}},
+			err:              ErrCounterfeit,
+			destinationState: Locked{},
+		},
+		{
+			name:             "several inputs",
+			inputs:           [][]any{{Coin(1)}, {Push{}}, {Push{}}},
+			destinationState: Locked{1},
+		},
+		{
+			name:             "ε-transition",
+			inputs:           [][]any{{Coin(1)}, {Push{}}, {Kick{}, Push{}}},
+			destinationState: Locked{},
+		},
+		{
+			name:             "transition doesn't exist",
+			inputs:           [][]any{{Kick{}}},
+			err:              ekstatic.ErrTransitionDoesNotExist,
+			destinationState: Locked{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			instances := []ekstatic.Instance{NewWorkflow().New(Locked{}), NewDispatcher(Locked{})}
+			for _, instance := range instances {
+				var err error
+				for _, input := range tt.inputs {
+					err = instance.ContinueWith(input...)
+				}
+
+				require.Equal(t, tt.err, err)
+				require.Equal(t, tt.destinationState, instance.CurrentState())
+			}
+		})
+	}
+
+	require.PanicsWithValue(t, "initial state must not be nil", func() { NewDispatcher(nil) })
+}
+
+func benchmarkInstance(b *testing.B, instance ekstatic.Instance) {
+	for i := 0; i < b.N; i++ {
+		_ = instance.ContinueWith(Coin(1))
+		_ = instance.ContinueWith(Push{})
+	}
+}
+
+func BenchmarkWorkflowInstance_ContinueWith(b *testing.B) {
+	benchmarkInstance(b, NewWorkflow().New(Locked{}))
+}
+
+func BenchmarkDispatcher_ContinueWith(b *testing.B) {
+	benchmarkInstance(b, NewDispatcher(Locked{}))
+}
@@ -0,0 +1,53 @@
+// Command ekstaticgen generates a dispatcher that performs the transitions of
+// a workflow without reflection. The transitions are read from a package
+// level variable holding the names of functions declared in the package:
+//
+//	var orderTransitions = []ekstatic.Transition{
+//		payOrder,
+//		shipOrder,
+//	}
+//
+//	//go:generate go run github.com/metamogul/ekstatic/cmd/ekstaticgen -type OrderDispatcher -transitions orderTransitions
+//
+// The generated type implements ekstatic.Instance. It only supports plain
+// transitions: emitters, substates, submachines and the options of a
+// Workflow aren't available. Transitions accepting interface types are
+// rejected, since a WorkflowInstance only matches the exact types of states
+// and inputs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		typeName       = flag.String("type", "", "name of the generated dispatcher type")
+		transitionsVar = flag.String("transitions", "", "name of the variable listing the transitions")
+		output         = flag.String("output", "", "output file name; default <type>_ekstatic.go")
+	)
+	flag.Parse()
+
+	if *typeName == "" || *transitionsVar == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_ekstatic.go"
+	}
+
+	source, err := generate(".", *output, *typeName, *transitionsVar)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ekstaticgen:", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "ekstaticgen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,278 @@
+// Package analyzer checks the transitions passed to ekstatic workflows.
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const ekstaticPath = "github.com/metamogul/ekstatic"
+
+// Analyzer reports transitions passed to AddTransition, AddTransitions and
+// ReplaceTransition that would make them panic, and transitions added to the
+// same workflow twice for the same state and input types without being
+// removed in between, unless they are added in branches of an if or switch
+// statement that exclude each other.
+var Analyzer = &analysis.Analyzer{
+	Name:     "ekstaticvet",
+	Doc:      "check the signatures of ekstatic transitions",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// The transitions added so far, by workflow and identifier
+	added := make(map[addedKey][]addedCall)
+
+	inspect.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		call := n.(*ast.CallExpr)
+
+		selector, isSelector := call.Fun.(*ast.SelectorExpr)
+		if !isSelector || !isWorkflow(pass.TypesInfo.TypeOf(selector.X)) {
+			return true
+		}
+
+		workflow := workflowKey(pass, selector.X)
+
+		var transitions []ast.Expr
+		switch selector.Sel.Name {
+		case "AddTransition", "ReplaceTransition":
+			if len(call.Args) > 0 {
+				transitions = call.Args[:1]
+			}
+		case "AddTransitions":
+			if call.Ellipsis == token.NoPos {
+				transitions = call.Args
+			}
+		case "RemoveTransition":
+			forget(pass, added, workflow, call.Args)
+			return true
+		default:
+			return true
+		}
+
+		for _, transition := range transitions {
+			identifier, valid := checkTransition(pass, transition)
+			if !valid || selector.Sel.Name == "ReplaceTransition" {
+				continue
+			}
+
+			key := addedKey{workflow, identifier}
+			if previous, exists := duplicate(added[key], stack); exists {
+				pass.Reportf(transition.Pos(), "there already is a transition for that state and input type at %s",
+					pass.Fset.Position(previous.pos))
+				continue
+			}
+
+			added[key] = append(added[key], addedCall{transition.Pos(), slices.Clone(stack)})
+		}
+
+		return true
+	})
+
+	return nil, nil
+}
+
+type (
+	// addedKey identifies the transitions added to a workflow for the same
+	// state and input types.
+	addedKey struct {
+		workflow   string
+		identifier string
+	}
+
+	// addedCall is a transition passed to a workflow along with the nodes
+	// enclosing the call.
+	addedCall struct {
+		pos   token.Pos
+		stack []ast.Node
+	}
+)
+
+// duplicate returns the first of the calls that isn't in a branch excluding
+// the one of the call with the given stack.
+func duplicate(calls []addedCall, stack []ast.Node) (addedCall, bool) {
+	for _, call := range calls {
+		if !exclusive(call.stack, stack) {
+			return call, true
+		}
+	}
+
+	return addedCall{}, false
+}
+
+// exclusive reports whether the nodes with the given stacks are in different
+// branches of the same if or switch statement. Nodes in different files or
+// functions, or in blocks enclosing each other, can both be run.
+func exclusive(a, b []ast.Node) bool {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	if i == 0 || i >= len(a) || i >= len(b) {
+		return false
+	}
+
+	switch parent := a[i-1].(type) {
+	case *ast.IfStmt:
+		return (a[i] == parent.Body && b[i] == parent.Else) || (a[i] == parent.Else && b[i] == parent.Body)
+	case *ast.BlockStmt:
+		// The body of a switch or select statement, whose clauses exclude
+		// each other unless they fall through
+		first, isCase := a[i].(*ast.CaseClause)
+		second, _ := b[i].(*ast.CaseClause)
+		if isCase && second != nil {
+			return !fallsThrough(first) && !fallsThrough(second)
+		}
+		_, isComm := a[i].(*ast.CommClause)
+		_, isOtherComm := b[i].(*ast.CommClause)
+		return isComm && isOtherComm
+	}
+
+	return false
+}
+
+func fallsThrough(clause *ast.CaseClause) bool {
+	if len(clause.Body) == 0 {
+		return false
+	}
+
+	branch, isBranch := clause.Body[len(clause.Body)-1].(*ast.BranchStmt)
+	return isBranch && branch.Tok == token.FALLTHROUGH
+}
+
+// forget removes the transitions for the state and input types passed to
+// RemoveTransition, or all transitions of the workflow if the types aren't
+// known statically.
+func forget(pass *analysis.Pass, added map[addedKey][]addedCall, workflow string, args []ast.Expr) {
+	argTypes := make([]types.Type, len(args))
+	for i, arg := range args {
+		argTypes[i] = types.Default(pass.TypesInfo.TypeOf(arg))
+		if argTypes[i] == nil || types.IsInterface(argTypes[i]) || types.Identical(argTypes[i], types.Typ[types.UntypedNil]) {
+			argTypes = nil
+			break
+		}
+	}
+
+	identifier := typesIdentifier(argTypes)
+
+	for key := range added {
+		if key.workflow == workflow && (argTypes == nil || key.identifier == identifier) {
+			delete(added, key)
+		}
+	}
+}
+
+// checkTransition reports the problems of the transition and returns the
+// identifier of its state and input types if it is valid.
+func checkTransition(pass *analysis.Pass, transition ast.Expr) (string, bool) {
+	t := pass.TypesInfo.TypeOf(transition)
+	if t == nil || types.IsInterface(t) {
+		return "", false
+	}
+
+	if types.Identical(t, types.Typ[types.UntypedNil]) {
+		pass.Reportf(transition.Pos(), "transition must not be nil")
+		return "", false
+	}
+
+	signature, isFunc := t.Underlying().(*types.Signature)
+	if !isFunc {
+		pass.Reportf(transition.Pos(), "transition must be of kind func")
+		return "", false
+	}
+
+	params, results := signature.Params(), signature.Results()
+
+	switch {
+	case params.Len() < 1:
+		pass.Reportf(transition.Pos(), "transition must accept at least a state argument")
+	case results.Len() < 1:
+		pass.Reportf(transition.Pos(), "transition must return at least result state")
+	case results.Len() > 2:
+		pass.Reportf(transition.Pos(), "transition must not have more than two return values")
+	case results.Len() == 2 && !types.Identical(results.At(1).Type(), types.Universe.Lookup("error").Type()):
+		pass.Reportf(transition.Pos(), "second return value of transition must be error")
+	default:
+		return identifier(params), true
+	}
+
+	return "", false
+}
+
+// identifier mirrors the identifier ekstatic builds from the parameter types
+// of a transition, skipping a trailing Emitter.
+func identifier(params *types.Tuple) string {
+	n := params.Len()
+	if n > 1 && isEkstaticType(params.At(n-1).Type(), "Emitter") {
+		n--
+	}
+
+	paramTypes := make([]types.Type, n)
+	for i := range paramTypes {
+		paramTypes[i] = params.At(i).Type()
+	}
+
+	return typesIdentifier(paramTypes)
+}
+
+func typesIdentifier(paramTypes []types.Type) string {
+	var b strings.Builder
+	for _, t := range paramTypes {
+		b.WriteString(types.TypeString(t, func(p *types.Package) string { return p.Name() }))
+		b.WriteString(";")
+	}
+
+	return b.String()
+}
+
+// workflowKey identifies the workflow a method is called on by the variable
+// it is stored in, or by the expression if there is none.
+func workflowKey(pass *analysis.Pass, x ast.Expr) string {
+	var ident *ast.Ident
+	switch x := x.(type) {
+	case *ast.Ident:
+		ident = x
+	case *ast.SelectorExpr:
+		ident = x.Sel
+	}
+
+	if ident != nil {
+		if object := pass.TypesInfo.ObjectOf(ident); object != nil {
+			return pass.Fset.Position(object.Pos()).String()
+		}
+	}
+
+	return types.ExprString(x)
+}
+
+func isWorkflow(t types.Type) bool {
+	if pointer, isPointer := t.(*types.Pointer); isPointer {
+		t = pointer.Elem()
+	}
+
+	return isEkstaticType(t, "Workflow")
+}
+
+func isEkstaticType(t types.Type, name string) bool {
+	named, isNamed := t.(*types.Named)
+	if !isNamed {
+		return false
+	}
+
+	object := named.Obj()
+	return object.Pkg() != nil && object.Pkg().Path() == ekstaticPath && object.Name() == name
+}
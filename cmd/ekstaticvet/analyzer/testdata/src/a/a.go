@@ -0,0 +1,87 @@
+package a
+
+import "github.com/metamogul/ekstatic"
+
+type (
+	stateA struct{}
+	stateB struct{}
+	input  struct{}
+)
+
+func (stateA) next(i input) stateB { return stateB{} }
+
+func valid() {
+	w := ekstatic.NewWorkflow()
+	w.AddTransition(func(s stateA, i input) stateB { return stateB{} })
+	w.AddTransition(func(s stateB, i input, e ekstatic.Emitter) (stateA, error) { return stateA{}, nil })
+	w.AddTransitions(stateA{}.next, func(s stateB) stateA { return stateA{} })
+	w.ReplaceTransition(func(s stateA, i input) stateB { return stateB{} })
+
+	other := ekstatic.NewWorkflow()
+	other.AddTransition(func(s stateA, i input) stateB { return stateB{} })
+
+	transitions := []ekstatic.Transition{func(s stateA, i input) stateB { return stateB{} }}
+	other.AddTransitions(transitions...)
+	other.AddTransition(transitions[0])
+}
+
+func invalid() {
+	w := ekstatic.NewWorkflow()
+	w.AddTransition(nil)                                                                 // want "transition must not be nil"
+	w.AddTransition("foo")                                                               // want "transition must be of kind func"
+	w.AddTransition(func() stateA { return stateA{} })                                   // want "transition must accept at least a state argument"
+	w.AddTransition(func(s stateA) {})                                                   // want "transition must return at least result state"
+	w.AddTransition(func(s stateA) (stateB, stateB, error) { return s.b(), s.b(), nil }) // want "transition must not have more than two return values"
+	w.AddTransitions(
+		func(s stateA) (stateB, bool) { return stateB{}, false }, // want "second return value of transition must be error"
+		func(s stateA, i input) stateB { return stateB{} },
+		func(s stateA, i input, e ekstatic.Emitter) stateA { return s }, // want "there already is a transition for that state and input type at .*a.go:37:3"
+	)
+}
+
+func (stateA) b() stateB { return stateB{} }
+
+func removed(state any) {
+	w := ekstatic.NewWorkflow()
+	w.AddTransition(func(s stateA, i input) stateB { return stateB{} })
+	w.RemoveTransition(stateA{}, input{})
+	w.AddTransition(func(s stateA, i input) stateB { return stateB{} })
+	w.RemoveTransition(state, input{})
+	w.AddTransition(func(s stateA, i input) stateB { return stateB{} })
+	w.RemoveTransition(stateB{}, input{})
+	w.AddTransition(func(s stateA, i input) stateB { return stateB{} }) // want "there already is a transition for that state and input type at .*a.go:50:18"
+}
+
+func branches(alternative bool) {
+	w := ekstatic.NewWorkflow()
+	if alternative {
+		w.AddTransition(func(s stateB, i input) stateA { return stateA{} })
+	} else {
+		w.AddTransition(func(s stateB, i input) stateA { return stateA{} })
+	}
+
+	switch {
+	case alternative:
+		w.AddTransition(func(s stateB) stateA { return stateA{} })
+	default:
+		w.AddTransition(func(s stateB) stateA { return stateA{} })
+	}
+}
+
+func nested(condition bool) {
+	w := ekstatic.NewWorkflow()
+	w.AddTransition(func(s stateB, i input) stateA { return stateA{} })
+	if condition {
+		w.AddTransition(func(s stateB, i input) stateA { return stateA{} }) // want "there already is a transition for that state and input type at .*a.go:73:18"
+	}
+}
+
+var global = ekstatic.NewWorkflow()
+
+func init() {
+	global.AddTransition(func(s stateB, i input) stateA { return stateA{} })
+}
+
+func init() {
+	global.AddTransition(func(s stateB, i input) stateA { return stateA{} }) // want "there already is a transition for that state and input type at .*a.go:82:23"
+}
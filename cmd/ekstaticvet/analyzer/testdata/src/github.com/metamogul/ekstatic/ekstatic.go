@@ -0,0 +1,15 @@
+package ekstatic
+
+type (
+	Transition       any
+	TransitionOption func()
+	Workflow         struct{}
+	Emitter          interface{ Emit(input ...any) error }
+)
+
+func NewWorkflow() *Workflow { return &Workflow{} }
+
+func (w *Workflow) AddTransition(t Transition, options ...TransitionOption)     {}
+func (w *Workflow) AddTransitions(transitions ...Transition)                    {}
+func (w *Workflow) ReplaceTransition(t Transition, options ...TransitionOption) {}
+func (w *Workflow) RemoveTransition(state any, input ...any)                    {}
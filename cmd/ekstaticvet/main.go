@@ -0,0 +1,20 @@
+// Command ekstaticvet checks the transitions passed to ekstatic workflows.
+//
+// It is run through go vet:
+//
+//	go vet -vettool=$(which ekstaticvet) ./...
+//
+// Running it on its own isn't supported, since the version of
+// golang.org/x/tools compatible with the Go version of this module fails to
+// load packages built with Go 1.25 or later.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/metamogul/ekstatic/cmd/ekstaticvet/analyzer"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
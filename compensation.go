@@ -0,0 +1,117 @@
+package ekstatic
+
+type (
+	// Compensation undoes the effects of a transition from previousState to
+	// newState with the given input.
+	Compensation func(newState, previousState any, input ...any) error
+
+	CompensationPolicy int
+
+	compensationStep struct {
+		undo          Compensation
+		newState      any
+		previousState any
+		input         []any
+	}
+)
+
+const (
+	// CompensateManually only runs compensations when Compensate is called.
+	CompensateManually CompensationPolicy = iota
+	// CompensateOnFailure runs compensations whenever a transition fails.
+	CompensateOnFailure
+)
+
+// WithCompensation registers a function that undoes the effects of the
+// transition. Instances record the compensations of the transitions they
+// perform and run them in reverse order when compensating. The record is
+// discarded once an instance reaches a final state. It isn't part of the
+// Snapshot of an instance.
+func WithCompensation(undo Compensation) TransitionOption {
+	if undo == nil {
+		panic("compensation must not be nil")
+	}
+
+	return func(c *transitionConfig) {
+		c.compensation = undo
+	}
+}
+
+func (w *Workflow) SetCompensationPolicy(policy CompensationPolicy) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.compensationPolicy = policy
+}
+
+// Compensate runs the compensations of all transitions performed since the
+// instance was created or last reached a final state, most recent first.
+// After each compensation, the instance returns to the state the
+// compensated transition started from, which is reported to the success
+// actions of the workflow along with the input of the transition. If a
+// compensation fails, it is reported to the failure action, the remaining
+// ones are kept and the error is returned.
+func (w *WorkflowInstance) Compensate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.evicted {
+		return ErrInstanceEvicted
+	}
+
+	return w.withHistoryErrors(w.applyEmitted(w.compensate()))
+}
+
+func (w *WorkflowInstance) compensate() error {
+	for len(w.compensation) > 0 {
+		step := w.compensation[len(w.compensation)-1]
+
+		entry := HistoryEntry{
+			Time:          w.workflow.getClock().Now(),
+			PreviousState: w.currentState,
+			Input:         step.input,
+			Compensation:  true,
+		}
+
+		err := step.undo(step.newState, step.previousState, step.input...)
+		entry.Duration = w.workflow.getClock().Now().Sub(entry.Time)
+
+		if err != nil {
+			entry.Err = err
+			w.recordEntry(entry)
+			if w.workflow.onTransitionFailed != nil {
+				w.workflow.onTransitionFailed(err, w.currentState, step.input...)
+			}
+			return err
+		}
+
+		w.compensation = w.compensation[:len(w.compensation)-1]
+
+		currentState := w.currentState
+		w.currentState = step.previousState
+		w.enterState(currentState)
+		w.recordEntry(entry)
+		w.performSucceededActions(currentState, step.input...)
+	}
+
+	return nil
+}
+
+func (w *WorkflowInstance) recordCompensation(identifier transitionIdentifer, previousState any, input []any) {
+	if w.workflow.isFinal(w.currentState) {
+		w.compensation = nil
+		return
+	}
+
+	config, exists := w.workflow.transitionConfig(identifier)
+	if !exists || config.compensation == nil {
+		return
+	}
+
+	w.compensation = append(w.compensation, compensationStep{
+		undo:          config.compensation,
+		newState:      w.currentState,
+		previousState: previousState,
+		input:         input,
+	})
+}
@@ -0,0 +1,168 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateTripPlanned    struct{}
+	stateFlightReserved struct{}
+	stateHotelReserved  struct{}
+	stateCarReserved    struct{}
+	stateTripConfirmed  struct{}
+
+	inputReserve struct{}
+	inputConfirm bool
+)
+
+func newTripWorkflow(log *[]string, failingUndo bool) *Workflow {
+	undo := func(name string) Compensation {
+		return func(newState, previousState any, input ...any) error {
+			if failingUndo && name == "hotel" {
+				return errors.New("hotel cancellation failed")
+			}
+			*log = append(*log, fmt.Sprintf("cancel %s: %T -> %T", name, newState, previousState))
+			return nil
+		}
+	}
+
+	w := NewWorkflow()
+	w.AddTransition(func(stateTripPlanned, inputReserve) stateFlightReserved { return stateFlightReserved{} }, WithCompensation(undo("flight")))
+	w.AddTransition(func(stateFlightReserved, inputReserve) stateHotelReserved { return stateHotelReserved{} }, WithCompensation(undo("hotel")))
+	w.AddTransition(func(stateHotelReserved, inputReserve) stateCarReserved { return stateCarReserved{} }, WithCompensation(undo("car")))
+	w.AddTransition(func(s stateCarReserved, ok inputConfirm) (stateTripConfirmed, error) {
+		if !ok {
+			return stateTripConfirmed{}, errors.New("payment failed")
+		}
+		return stateTripConfirmed{}, nil
+	})
+	w.AddFinalStates(stateTripConfirmed{})
+
+	return w
+}
+
+func TestWithCompensation(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithValue(t, "compensation must not be nil", func() { WithCompensation(nil) })
+}
+
+func TestWorkflowInstance_Compensate(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	instance := newTripWorkflow(&log, false).New(stateTripPlanned{})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+	require.EqualError(t, instance.ContinueWith(inputConfirm(false)), "payment failed")
+	require.Empty(t, log)
+
+	require.NoError(t, instance.Compensate())
+	require.Equal(t, []string{
+		"cancel car: ekstatic.stateCarReserved -> ekstatic.stateHotelReserved",
+		"cancel hotel: ekstatic.stateHotelReserved -> ekstatic.stateFlightReserved",
+		"cancel flight: ekstatic.stateFlightReserved -> ekstatic.stateTripPlanned",
+	}, log)
+	require.Equal(t, stateTripPlanned{}, instance.CurrentState())
+
+	require.NoError(t, instance.Compensate())
+	require.Len(t, log, 3)
+}
+
+func TestWorkflowInstance_Compensate_failing(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	instance := newTripWorkflow(&log, true).New(stateTripPlanned{})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+
+	require.EqualError(t, instance.Compensate(), "hotel cancellation failed")
+	require.Equal(t, []string{"cancel car: ekstatic.stateCarReserved -> ekstatic.stateHotelReserved"}, log)
+	require.Equal(t, stateHotelReserved{}, instance.CurrentState())
+	require.Len(t, instance.compensation, 2)
+}
+
+func TestWorkflowInstance_Compensate_actions(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	w := newTripWorkflow(&log, true)
+
+	// Persist the state through the actions, as the examples do
+	var (
+		persisted any
+		failures  []error
+	)
+	w.AddTransitionSucceededAction(func(newState, previousState any, input ...any) {
+		require.Equal(t, []any{inputReserve{}}, input)
+		persisted = newState
+	})
+	w.AddTransitionFailedAction(func(err error, previousState any, input ...any) {
+		require.Equal(t, stateHotelReserved{}, previousState)
+		failures = append(failures, err)
+	})
+
+	instance := w.New(stateTripPlanned{})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+	require.Equal(t, stateCarReserved{}, persisted)
+
+	require.EqualError(t, instance.Compensate(), "hotel cancellation failed")
+	require.Equal(t, instance.CurrentState(), persisted)
+	require.Equal(t, stateHotelReserved{}, persisted)
+	require.EqualError(t, errors.Join(failures...), "hotel cancellation failed")
+}
+
+func TestWorkflowInstance_Compensate_finalState(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	instance := newTripWorkflow(&log, false).New(stateTripPlanned{})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+	require.NoError(t, instance.ContinueWith(inputConfirm(true)))
+
+	require.NoError(t, instance.Compensate())
+	require.Empty(t, log)
+	require.Equal(t, stateTripConfirmed{}, instance.CurrentState())
+}
+
+func TestWorkflow_SetCompensationPolicy(t *testing.T) {
+	t.Parallel()
+
+	var log []string
+	w := newTripWorkflow(&log, false)
+	w.SetCompensationPolicy(CompensateOnFailure)
+	instance := w.New(stateTripPlanned{})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+	require.EqualError(t, instance.ContinueWith(inputConfirm(false)), "payment failed")
+	require.Len(t, log, 3)
+	require.Equal(t, stateTripPlanned{}, instance.CurrentState())
+
+	var failingLog []string
+	failing := newTripWorkflow(&failingLog, true)
+	failing.SetCompensationPolicy(CompensateOnFailure)
+	instance = failing.New(stateTripPlanned{})
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, instance.ContinueWith(inputReserve{}))
+	}
+	err := instance.ContinueWith(inputConfirm(false))
+	require.ErrorContains(t, err, "payment failed")
+	require.ErrorContains(t, err, "hotel cancellation failed")
+}
@@ -0,0 +1,174 @@
+package ekstatic
+
+import (
+	"maps"
+	"slices"
+)
+
+// MergePolicy decides what Merge does when both workflows have a transition
+// for the same state and input types, or a timeout for the same state type.
+type MergePolicy int
+
+const (
+	// MergeKeepExisting keeps the transition or timeout of the workflow
+	// merged into.
+	MergeKeepExisting MergePolicy = iota
+	// MergeOverride replaces it with the one of the other workflow.
+	MergeOverride
+	// MergeFail makes Merge return an error without changing the workflow.
+	MergeFail
+)
+
+// Clone returns a copy of the workflow that can be extended or changed
+// without affecting the original, even if that is frozen. Instances and
+// scheduled inputs aren't shared between the two, the configured TimerStore
+// and Clock are.
+func (w *Workflow) Clone() *Workflow {
+	defer w.rlock()()
+
+	clone := &Workflow{
+		transitions:           maps.Clone(w.transitions),
+		onTransitionSucceeded: w.onTransitionSucceeded,
+		onTransitionEmitting:  w.onTransitionEmitting,
+		onTransitionFailed:    w.onTransitionFailed,
+		mailboxCapacity:       w.mailboxCapacity,
+		mailboxOverflow:       w.mailboxOverflow,
+		clock:                 w.clock,
+		timeouts:              maps.Clone(w.timeouts),
+		timerStore:            w.timerStore,
+		redeliveryPolicy:      w.redeliveryPolicy,
+		idempotencyWindow:     w.idempotencyWindow,
+		onDuplicateInput:      w.onDuplicateInput,
+		finalStates:           maps.Clone(w.finalStates),
+		transitionConfigs:     maps.Clone(w.transitionConfigs),
+		compensationPolicy:    w.compensationPolicy,
+		retryPolicy:           w.retryPolicy,
+		failureEdges:          make(map[string][]FailureEdge, len(w.failureEdges)),
+		historyCapacity:       w.historyCapacity,
+		historySink:           w.historySink,
+	}
+
+	for from, edges := range w.failureEdges {
+		clone.failureEdges[from] = slices.Clone(edges)
+	}
+
+	return clone
+}
+
+// Merge adds the transitions, timeouts, final states and failure edges of
+// other to the workflow. Conflicting transitions and timeouts are resolved
+// according to policy. Actions and policies of other aren't merged.
+func (w *Workflow) Merge(other *Workflow, policy MergePolicy) error {
+	if other == nil {
+		panic("merged workflow must not be nil")
+	}
+
+	if other == w {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.frozen.Load() {
+		return ErrWorkflowFrozen
+	}
+
+	defer other.rlock()()
+
+	if policy == MergeFail {
+		for identifier := range other.transitions {
+			if _, exists := w.transitions[identifier]; exists {
+				return ErrTransitionAlreadyExists
+			}
+		}
+		for stateType := range other.timeouts {
+			if _, exists := w.timeouts[stateType]; exists {
+				return ErrTimeoutAlreadyExists
+			}
+		}
+	}
+
+	for identifier, t := range other.transitions {
+		if _, exists := w.transitions[identifier]; exists && policy == MergeKeepExisting {
+			continue
+		}
+
+		w.transitions[identifier] = t
+
+		delete(w.transitionConfigs, identifier)
+		if config, exists := other.transitionConfigs[identifier]; exists {
+			if w.transitionConfigs == nil {
+				w.transitionConfigs = make(map[transitionIdentifer]transitionConfig)
+			}
+			w.transitionConfigs[identifier] = config
+		}
+	}
+
+	for stateType, t := range other.timeouts {
+		if _, exists := w.timeouts[stateType]; exists && policy == MergeKeepExisting {
+			continue
+		}
+
+		if w.timeouts == nil {
+			w.timeouts = make(map[string]timeout)
+		}
+		w.timeouts[stateType] = t
+	}
+
+	for stateType := range other.finalStates {
+		if w.finalStates == nil {
+			w.finalStates = make(map[string]struct{})
+		}
+		w.finalStates[stateType] = struct{}{}
+	}
+
+	for from, edges := range other.failureEdges {
+		if w.failureEdges == nil {
+			w.failureEdges = make(map[string][]FailureEdge)
+		}
+		w.failureEdges[from] = append(w.failureEdges[from], edges...)
+	}
+
+	return nil
+}
+
+// ReplaceTransition replaces the transition for the same state and input
+// types as t, along with its options. It panics with
+// ErrTransitionDoesNotExist if there is no such transition.
+func (w *Workflow) ReplaceTransition(t Transition, options ...TransitionOption) {
+	validateTransition(t)
+
+	w.lock()
+	defer w.mu.Unlock()
+
+	identifier := identifierFromTransition(t)
+
+	if _, transitionExists := w.transitions[identifier]; !transitionExists {
+		panic(ErrTransitionDoesNotExist)
+	}
+
+	w.transitions[identifier] = t
+	w.configureTransition(identifier, options)
+}
+
+// RemoveTransition removes the transition for the types of the given state
+// and input. It panics with ErrTransitionDoesNotExist if there is no such
+// transition.
+func (w *Workflow) RemoveTransition(state any, input ...any) {
+	if state == nil {
+		panic("state must not be nil")
+	}
+
+	w.lock()
+	defer w.mu.Unlock()
+
+	identifier := identifierFromArguments(state, input...)
+
+	if _, transitionExists := w.transitions[identifier]; !transitionExists {
+		panic(ErrTransitionDoesNotExist)
+	}
+
+	delete(w.transitions, identifier)
+	delete(w.transitionConfigs, identifier)
+}
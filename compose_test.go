@@ -0,0 +1,158 @@
+package ekstatic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBaseWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.SetClock(NewManualClock(testEpoch))
+	w.AddTransitions(
+		func(s string, i int) string { return s + "base" },
+		func(s string, b bool) string { return s + "bool" },
+	)
+	w.AddTimeout("", time.Second, func() any { return 0 })
+	w.AddFinalStates(0.0)
+
+	return w
+}
+
+func TestWorkflow_Clone(t *testing.T) {
+	t.Parallel()
+
+	base := newBaseWorkflow()
+	base.AddFailureEdges(OnError[error]("", 0.0))
+
+	clone := base.Clone()
+	clone.AddTransition(func(s string, f float64) string { return s + "clone" })
+	clone.ReplaceTransition(func(s string, i int) string { return s + "replaced" })
+	clone.RemoveTransition("", true)
+	clone.AddFailureEdges(OnError[error](0, ""))
+	clone.AddFinalStates(0)
+
+	instance := base.New("")
+	require.ErrorIs(t, instance.ContinueWith(1.0), ErrTransitionDoesNotExist)
+	require.NoError(t, instance.ContinueWith(1))
+	require.NoError(t, instance.ContinueWith(true))
+	require.Equal(t, "basebool", instance.CurrentState())
+
+	instance = clone.New("")
+	require.NoError(t, instance.ContinueWith(1.0))
+	require.NoError(t, instance.ContinueWith(1))
+	require.ErrorIs(t, instance.ContinueWith(true), ErrTransitionDoesNotExist)
+	require.Equal(t, "clonereplaced", instance.CurrentState())
+
+	require.Len(t, base.transitions, 2)
+	require.Len(t, base.failureEdges, 1)
+	require.Len(t, base.finalStates, 1)
+	require.Equal(t, base.timeouts["string"].duration, clone.timeouts["string"].duration)
+}
+
+func TestWorkflow_Merge(t *testing.T) {
+	t.Parallel()
+
+	newOther := func() *Workflow {
+		other := NewWorkflow()
+		other.AddTransitions(
+			func(s string, i int) string { return s + "other" },
+			func(s string, f float64) string { return s + "float" },
+		)
+		other.AddFinalStates(0)
+		return other
+	}
+
+	testcases := []struct {
+		name             string
+		policy           MergePolicy
+		other            func() *Workflow
+		err              error
+		destinationState string
+	}{
+		{
+			name:             "keep existing",
+			policy:           MergeKeepExisting,
+			other:            newOther,
+			destinationState: "basefloat",
+		},
+		{
+			name:             "override",
+			policy:           MergeOverride,
+			other:            newOther,
+			destinationState: "otherfloat",
+		},
+		{
+			name:             "fail on transition",
+			policy:           MergeFail,
+			other:            newOther,
+			err:              ErrTransitionAlreadyExists,
+			destinationState: "base",
+		},
+		{
+			name:   "fail on timeout",
+			policy: MergeFail,
+			other: func() *Workflow {
+				other := NewWorkflow()
+				other.AddTimeout("", time.Minute, func() any { return 1 })
+				return other
+			},
+			err:              ErrTimeoutAlreadyExists,
+			destinationState: "base",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w := newBaseWorkflow()
+			require.Equal(t, tt.err, w.Merge(tt.other(), tt.policy))
+
+			instance := w.New("")
+			require.NoError(t, instance.ContinueWith(1))
+			_ = instance.ContinueWith(1.0)
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+
+			if tt.err == nil {
+				require.True(t, w.isFinal(0))
+			}
+		})
+	}
+
+	require.PanicsWithValue(t, "merged workflow must not be nil", func() {
+		NewWorkflow().Merge(nil, MergeOverride)
+	})
+}
+
+func TestWorkflow_ReplaceTransition(t *testing.T) {
+	t.Parallel()
+
+	w := newBaseWorkflow()
+	w.AddTransition(func(s string, i uint) (string, error) { return s, nil }, WithRetry(RetryPolicy{MaxAttempts: 3}))
+
+	require.PanicsWithValue(t, ErrTransitionDoesNotExist, func() {
+		w.ReplaceTransition(func(s int, i int) int { return i })
+	})
+	require.PanicsWithValue(t, ErrTransitionIsNonFunc, func() {
+		w.ReplaceTransition("foo")
+	})
+
+	w.ReplaceTransition(func(s string, i uint) string { return s })
+	require.Equal(t, 3, len(w.transitions))
+	require.Empty(t, w.transitionConfigs)
+}
+
+func TestWorkflow_RemoveTransition(t *testing.T) {
+	t.Parallel()
+
+	w := newBaseWorkflow()
+
+	require.PanicsWithValue(t, "state must not be nil", func() { w.RemoveTransition(nil) })
+	require.PanicsWithValue(t, ErrTransitionDoesNotExist, func() { w.RemoveTransition("", "") })
+
+	w.RemoveTransition("", 0)
+	require.ErrorIs(t, w.New("").ContinueWith(1), ErrTransitionDoesNotExist)
+}
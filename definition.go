@@ -0,0 +1,254 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+var (
+	ErrUnknownFunction       = errors.New("function isn't registered")
+	ErrUnknownType           = errors.New("type isn't registered")
+	ErrUndeclaredState       = errors.New("state isn't declared")
+	ErrUndeclaredInput       = errors.New("input isn't declared")
+	ErrSignatureMismatch     = errors.New("function signature doesn't match the declared transition")
+	ErrFunctionAlreadyExists = errors.New("there already is a function with that name")
+	ErrTypeAlreadyExists     = errors.New("there already is a type with that name")
+	ErrDefinitionEmpty       = errors.New("workflow definition is empty")
+	ErrDefinitionMalformed   = errors.New("workflow definition is malformed")
+)
+
+type (
+	// FunctionRegistry maps the names used in workflow definitions to
+	// transition functions and to the types of states and inputs.
+	FunctionRegistry struct {
+		functions map[string]Transition
+		types     map[string]reflect.Type
+	}
+
+	// DefinitionError is returned for a problem in a workflow definition,
+	// along with the line of the definition it was found in.
+	DefinitionError struct {
+		Line int
+		Err  error
+	}
+
+	// WorkflowDefinition lists the states, inputs and transitions of a
+	// workflow by the names they are registered under in a FunctionRegistry.
+	// Formats like SCXML and the YAML of the package definition are decoded
+	// into it. Lines are those of the decoded document.
+	WorkflowDefinition struct {
+		States      []StateDefinition
+		Inputs      []InputDefinition
+		Transitions []TransitionDefinition
+	}
+
+	StateDefinition struct {
+		Name  string
+		Final bool
+		Line  int
+	}
+
+	InputDefinition struct {
+		Name string
+		Line int
+	}
+
+	// TransitionDefinition declares a transition from a state for the given
+	// inputs, or an ε-transition if there are none, that is performed by a
+	// registered function. Transitions returning an interface type may list
+	// several states in To, which are declared as their Targets.
+	TransitionDefinition struct {
+		From     string
+		Input    []string
+		To       []string
+		Function string
+		Line     int
+	}
+)
+
+func NewFunctionRegistry() *FunctionRegistry {
+	return &FunctionRegistry{
+		functions: make(map[string]Transition),
+		types:     make(map[string]reflect.Type),
+	}
+}
+
+// RegisterFunction makes the transition available under the given name. It
+// panics if the name is taken or the transition isn't valid.
+func (r *FunctionRegistry) RegisterFunction(name string, t Transition) {
+	validateTransition(t)
+
+	if _, exists := r.functions[name]; exists {
+		panic(ErrFunctionAlreadyExists)
+	}
+
+	r.functions[name] = t
+}
+
+// RegisterType makes the type of the given state or input available under
+// the given name. It panics if the name is taken.
+func (r *FunctionRegistry) RegisterType(name string, value any) {
+	if value == nil {
+		panic("type value must not be nil")
+	}
+
+	if _, exists := r.types[name]; exists {
+		panic(ErrTypeAlreadyExists)
+	}
+
+	r.types[name] = reflect.TypeOf(value)
+}
+
+// Build creates the workflow defined, resolving all names in the registry.
+// All problems found are returned as DefinitionErrors, joined with
+// errors.Join.
+func (d WorkflowDefinition) Build(registry *FunctionRegistry) (*Workflow, error) {
+	if registry == nil {
+		panic("function registry must not be nil")
+	}
+
+	var (
+		w      = NewWorkflow()
+		errs   []error
+		states = make(map[string]reflect.Type)
+		inputs = make(map[string]reflect.Type)
+		final  []any
+	)
+
+	fail := func(line int, err error) {
+		errs = append(errs, &DefinitionError{line, err})
+	}
+
+	for _, state := range d.States {
+		t, exists := registry.types[state.Name]
+		if !exists {
+			fail(state.Line, fmt.Errorf("%w: %s", ErrUnknownType, state.Name))
+			continue
+		}
+
+		states[state.Name] = t
+		if state.Final {
+			final = append(final, reflect.New(t).Elem().Interface())
+		}
+	}
+
+	for _, input := range d.Inputs {
+		t, exists := registry.types[input.Name]
+		if !exists {
+			fail(input.Line, fmt.Errorf("%w: %s", ErrUnknownType, input.Name))
+			continue
+		}
+
+		inputs[input.Name] = t
+	}
+
+	for _, transition := range d.Transitions {
+		t, options, err := transition.resolve(registry, states, inputs)
+		if err != nil {
+			fail(transition.Line, err)
+			continue
+		}
+
+		if err := addTransition(w, t, options); err != nil {
+			fail(transition.Line, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	w.AddFinalStates(final...)
+
+	return w, nil
+}
+
+func (e *DefinitionError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+}
+
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// resolve looks up the function of the transition and checks its signature
+// against the declared states and inputs.
+func (d TransitionDefinition) resolve(registry *FunctionRegistry, states, inputs map[string]reflect.Type) (Transition, []TransitionOption, error) {
+	t, exists := registry.functions[d.Function]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownFunction, d.Function)
+	}
+
+	from, exists := states[d.From]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUndeclaredState, d.From)
+	}
+
+	var inputTypes []reflect.Type
+	for _, name := range d.Input {
+		input, exists := inputs[name]
+		if !exists {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUndeclaredInput, name)
+		}
+		inputTypes = append(inputTypes, input)
+	}
+
+	var targets []any
+	for _, name := range d.To {
+		to, exists := states[name]
+		if !exists {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUndeclaredState, name)
+		}
+		targets = append(targets, reflect.New(to).Elem().Interface())
+	}
+
+	descriptor := describeTransition(t, transitionConfig{})
+
+	mismatch := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s %s", ErrSignatureMismatch, d.Function, fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case descriptor.State != from:
+		return nil, nil, mismatch("accepts %s instead of %s", descriptor.State, from)
+	case !slices.Equal(descriptor.Inputs, inputTypes):
+		return nil, nil, mismatch("accepts input %s instead of %s", typeNames(descriptor.Inputs), typeNames(inputTypes))
+	case len(targets) == 0:
+		return nil, nil, mismatch("has no declared result state")
+	}
+
+	if descriptor.Result.Kind() == reflect.Interface {
+		for _, target := range targets {
+			if !reflect.TypeOf(target).AssignableTo(descriptor.Result) {
+				return nil, nil, mismatch("can't return %s", reflect.TypeOf(target))
+			}
+		}
+		return t, []TransitionOption{Targets(targets...)}, nil
+	}
+
+	if len(targets) != 1 || reflect.TypeOf(targets[0]) != descriptor.Result {
+		return nil, nil, mismatch("returns %s instead of %s", descriptor.Result, fmt.Sprint(d.To))
+	}
+
+	return t, nil, nil
+}
+
+// addTransition adds the transition to the workflow and returns the error
+// AddTransition panics with instead.
+func addTransition(w *Workflow, t Transition, options []TransitionOption) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if recovered, isError := r.(error); isError {
+				err = recovered
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	w.AddTransition(t, options...)
+
+	return nil
+}
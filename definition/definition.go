@@ -0,0 +1,175 @@
+// Package definition loads ekstatic workflows from YAML and JSON definitions.
+// It is kept apart from the core package, which doesn't depend on third party
+// modules.
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/metamogul/ekstatic"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	workflowDefinition struct {
+		States      []stateDefinition      `yaml:"states"`
+		Inputs      []nameDefinition       `yaml:"inputs"`
+		Transitions []transitionDefinition `yaml:"transitions"`
+	}
+
+	stateDefinition struct {
+		Name  string `yaml:"name"`
+		Final bool   `yaml:"final"`
+
+		line int
+	}
+
+	nameDefinition struct {
+		Name string
+
+		line int
+	}
+
+	transitionDefinition struct {
+		From     string   `yaml:"from"`
+		Input    nameList `yaml:"input"`
+		To       nameList `yaml:"to"`
+		Function string   `yaml:"function"`
+
+		line int
+	}
+
+	nameList []string
+)
+
+// LoadWorkflow creates a workflow from a YAML or JSON definition listing
+// states, inputs and transitions by the names they are registered under:
+//
+//	states:
+//	  - name: Locked
+//	  - name: Unlocked
+//	  - name: Done
+//	    final: true
+//	inputs: [Coin, Push]
+//	transitions:
+//	  - from: Locked
+//	    input: Coin
+//	    to: Unlocked
+//	    function: insertCoin
+//
+// Transitions without input are ε-transitions, and transitions returning an
+// interface type may list several states as to, which are declared as their
+// Targets. All problems found are returned as ekstatic.DefinitionErrors,
+// joined with errors.Join.
+func LoadWorkflow(r io.Reader, registry *ekstatic.FunctionRegistry) (*ekstatic.Workflow, error) {
+	if registry == nil {
+		panic("function registry must not be nil")
+	}
+
+	var definition workflowDefinition
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&definition); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ekstatic.ErrDefinitionEmpty
+		}
+		return nil, fmt.Errorf("%w: %w", ekstatic.ErrDefinitionMalformed, err)
+	}
+
+	return definition.convert().Build(registry)
+}
+
+func (d workflowDefinition) convert() ekstatic.WorkflowDefinition {
+	var definition ekstatic.WorkflowDefinition
+
+	for _, state := range d.States {
+		definition.States = append(definition.States, ekstatic.StateDefinition{
+			Name:  state.Name,
+			Final: state.Final,
+			Line:  state.line,
+		})
+	}
+
+	for _, input := range d.Inputs {
+		definition.Inputs = append(definition.Inputs, ekstatic.InputDefinition{
+			Name: input.Name,
+			Line: input.line,
+		})
+	}
+
+	for _, transition := range d.Transitions {
+		definition.Transitions = append(definition.Transitions, ekstatic.TransitionDefinition{
+			From:     transition.From,
+			Input:    transition.Input,
+			To:       transition.To,
+			Function: transition.Function,
+			Line:     transition.line,
+		})
+	}
+
+	return definition
+}
+
+func (d *stateDefinition) UnmarshalYAML(node *yaml.Node) error {
+	if err := checkFields(node, "name", "final"); err != nil {
+		return err
+	}
+
+	type plain stateDefinition
+	if err := node.Decode((*plain)(d)); err != nil {
+		return err
+	}
+
+	d.line = node.Line
+	return nil
+}
+
+func (d *nameDefinition) UnmarshalYAML(node *yaml.Node) error {
+	d.line = node.Line
+	return node.Decode(&d.Name)
+}
+
+func (d *transitionDefinition) UnmarshalYAML(node *yaml.Node) error {
+	if err := checkFields(node, "from", "input", "to", "function"); err != nil {
+		return err
+	}
+
+	type plain transitionDefinition
+	if err := node.Decode((*plain)(d)); err != nil {
+		return err
+	}
+
+	d.line = node.Line
+	return nil
+}
+
+// checkFields returns an error if the mapping node has other keys than the
+// given ones, since nested decoding doesn't honor KnownFields.
+func checkFields(node *yaml.Node, fields ...string) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !slices.Contains(fields, key.Value) {
+			return fmt.Errorf("line %d: field %s not found", key.Line, key.Value)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalYAML accepts a single name as well as a list of names.
+func (l *nameList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = nameList{node.Value}
+		return nil
+	}
+
+	return node.Decode((*[]string)(l))
+}
@@ -0,0 +1,197 @@
+package definition
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/metamogul/ekstatic"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateCharging struct{}
+	stateCharged  struct{}
+	stateDeclined struct{}
+
+	inputCharge int
+	inputCancel struct{}
+)
+
+func newChargeRegistry() *ekstatic.FunctionRegistry {
+	registry := ekstatic.NewFunctionRegistry()
+	registry.RegisterType("Charging", stateCharging{})
+	registry.RegisterType("Charged", stateCharged{})
+	registry.RegisterType("Declined", stateDeclined{})
+	registry.RegisterType("Charge", inputCharge(0))
+	registry.RegisterType("Cancel", inputCancel{})
+	registry.RegisterFunction("charge", func(s stateCharging, amount inputCharge) (stateCharged, error) {
+		if amount > 100 {
+			return stateCharged{}, errors.New("limit exceeded")
+		}
+		return stateCharged{}, nil
+	})
+	registry.RegisterFunction("review", func(s stateCharged, c inputCancel) any { return stateDeclined{} })
+	registry.RegisterFunction("settle", func(s stateDeclined) stateCharging { return stateCharging{} })
+
+	return registry
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name       string
+		definition string
+	}{
+		{
+			name: "yaml",
+			definition: `
+states:
+  - name: Charging
+  - name: Charged
+  - name: Declined
+    final: true
+inputs: [Charge, Cancel]
+transitions:
+  - from: Charging
+    input: Charge
+    to: Charged
+    function: charge
+  - from: Charged
+    input: [Cancel]
+    to: [Declined, Charging]
+    function: review
+`,
+		},
+		{
+			name: "json",
+			definition: `{
+  "states": [{"name": "Charging"}, {"name": "Charged"}, {"name": "Declined", "final": true}],
+  "inputs": ["Charge", "Cancel"],
+  "transitions": [
+    {"from": "Charging", "input": "Charge", "to": "Charged", "function": "charge"},
+    {"from": "Charged", "input": ["Cancel"], "to": ["Declined", "Charging"], "function": "review"}
+  ]
+}`,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w, err := LoadWorkflow(strings.NewReader(tt.definition), newChargeRegistry())
+			require.NoError(t, err)
+
+			instance := w.New(stateCharging{})
+			require.EqualError(t, instance.ContinueWith(inputCharge(200)), "limit exceeded")
+			require.NoError(t, instance.ContinueWith(inputCharge(10)))
+			require.NoError(t, instance.ContinueWith(inputCancel{}))
+			require.Equal(t, stateDeclined{}, instance.CurrentState())
+			require.True(t, instance.IsFinal())
+
+			descriptors := w.Transitions()
+			require.Len(t, descriptors, 2)
+			require.Len(t, descriptors[0].Results, 2)
+		})
+	}
+}
+
+func TestLoadWorkflow_errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name       string
+		definition string
+		errs       []error
+		message    string
+	}{
+		{
+			name:       "empty",
+			definition: "",
+			errs:       []error{ekstatic.ErrDefinitionEmpty},
+			message:    "workflow definition is empty",
+		},
+		{
+			name:       "malformed",
+			definition: "states: {",
+			errs:       []error{ekstatic.ErrDefinitionMalformed},
+		},
+		{
+			name: "unknown field",
+			definition: `states:
+  - name: Charging
+    initial: true`,
+			errs:    []error{ekstatic.ErrDefinitionMalformed},
+			message: "workflow definition is malformed: line 3: field initial not found",
+		},
+		{
+			name: "unknown types",
+			definition: `states:
+  - name: Charging
+  - name: Paid
+inputs:
+  - Refund`,
+			errs:    []error{ekstatic.ErrUnknownType},
+			message: "line 3: type isn't registered: Paid\nline 5: type isn't registered: Refund",
+		},
+		{
+			name: "undeclared states and inputs",
+			definition: `states: [{name: Charging}]
+inputs: [Charge]
+transitions:
+  - {from: Charged, input: Cancel, to: Declined, function: review}
+  - {from: Charging, input: Cancel, to: Charged, function: charge}
+  - {from: Charging, input: Charge, to: Charged, function: charge}`,
+			errs: []error{ekstatic.ErrUndeclaredState, ekstatic.ErrUndeclaredInput},
+			message: "line 4: state isn't declared: Charged\nline 5: input isn't declared: Cancel\n" +
+				"line 6: state isn't declared: Charged",
+		},
+		{
+			name: "signature mismatches",
+			definition: `states: [{name: Charging}, {name: Charged}, {name: Declined}]
+inputs: [Charge, Cancel]
+transitions:
+  - {from: Charged, input: Charge, to: Charged, function: charge}
+  - {from: Charging, input: [Charge, Cancel], to: Charged, function: charge}
+  - {from: Charging, input: Charge, to: [Charged, Declined], function: charge}
+  - {from: Charged, input: Cancel, to: Charged, function: unknown}`,
+			errs: []error{ekstatic.ErrSignatureMismatch, ekstatic.ErrUnknownFunction},
+			message: "line 4: function signature doesn't match the declared transition: charge accepts definition.stateCharging instead of definition.stateCharged\n" +
+				"line 5: function signature doesn't match the declared transition: charge accepts input definition.inputCharge instead of definition.inputCharge, definition.inputCancel\n" +
+				"line 6: function signature doesn't match the declared transition: charge returns definition.stateCharged instead of [Charged Declined]\n" +
+				"line 7: function isn't registered: unknown",
+		},
+		{
+			name: "duplicate transition",
+			definition: `states: [{name: Declined}, {name: Charging}]
+transitions:
+  - {from: Declined, to: Charging, function: settle}
+  - {from: Declined, to: Charging, function: settle}`,
+			errs:    []error{ekstatic.ErrTransitionAlreadyExists},
+			message: "line 4: there already is a transition for that state and input type",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w, err := LoadWorkflow(strings.NewReader(tt.definition), newChargeRegistry())
+			require.Nil(t, w)
+			for _, target := range tt.errs {
+				require.ErrorIs(t, err, target)
+			}
+			if tt.message != "" {
+				require.EqualError(t, err, tt.message)
+			}
+		})
+	}
+
+	require.PanicsWithValue(t, "function registry must not be nil", func() {
+		_, _ = LoadWorkflow(strings.NewReader(""), nil)
+	})
+}
@@ -0,0 +1,171 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newChargeRegistry() *FunctionRegistry {
+	registry := NewFunctionRegistry()
+	registry.RegisterType("Charging", stateCharging{})
+	registry.RegisterType("Charged", stateCharged{})
+	registry.RegisterType("Declined", stateDeclined{})
+	registry.RegisterType("Charge", inputCharge(0))
+	registry.RegisterType("Cancel", inputCancel{})
+	registry.RegisterFunction("charge", func(s stateCharging, amount inputCharge) (stateCharged, error) {
+		if amount > 100 {
+			return stateCharged{}, errors.New("limit exceeded")
+		}
+		return stateCharged{}, nil
+	})
+	registry.RegisterFunction("review", func(s stateCharged, c inputCancel) any { return stateDeclined{} })
+	registry.RegisterFunction("settle", func(s stateDeclined) stateCharging { return stateCharging{} })
+
+	return registry
+}
+
+func TestFunctionRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := newChargeRegistry()
+
+	require.PanicsWithValue(t, ErrFunctionAlreadyExists, func() {
+		registry.RegisterFunction("charge", func(s string) string { return s })
+	})
+	require.PanicsWithValue(t, ErrTransitionIsNonFunc, func() { registry.RegisterFunction("foo", "foo") })
+	require.PanicsWithValue(t, ErrTypeAlreadyExists, func() { registry.RegisterType("Charged", stateCharged{}) })
+	require.PanicsWithValue(t, "type value must not be nil", func() { registry.RegisterType("nil", nil) })
+}
+
+func TestWorkflowDefinition_Build(t *testing.T) {
+	t.Parallel()
+
+	definition := WorkflowDefinition{
+		States: []StateDefinition{
+			{Name: "Charging"},
+			{Name: "Charged"},
+			{Name: "Declined", Final: true},
+		},
+		Inputs: []InputDefinition{{Name: "Charge"}, {Name: "Cancel"}},
+		Transitions: []TransitionDefinition{
+			{From: "Charging", Input: []string{"Charge"}, To: []string{"Charged"}, Function: "charge"},
+			{From: "Charged", Input: []string{"Cancel"}, To: []string{"Declined", "Charging"}, Function: "review"},
+		},
+	}
+
+	w, err := definition.Build(newChargeRegistry())
+	require.NoError(t, err)
+
+	instance := w.New(stateCharging{})
+	require.EqualError(t, instance.ContinueWith(inputCharge(200)), "limit exceeded")
+	require.NoError(t, instance.ContinueWith(inputCharge(10)))
+	require.NoError(t, instance.ContinueWith(inputCancel{}))
+	require.Equal(t, stateDeclined{}, instance.CurrentState())
+	require.True(t, instance.IsFinal())
+
+	descriptors := w.Transitions()
+	require.Len(t, descriptors, 2)
+	require.Len(t, descriptors[0].Results, 2)
+
+	require.PanicsWithValue(t, "function registry must not be nil", func() { _, _ = definition.Build(nil) })
+}
+
+func TestWorkflowDefinition_Build_errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name       string
+		definition WorkflowDefinition
+		errs       []error
+		message    string
+	}{
+		{
+			name: "unknown types",
+			definition: WorkflowDefinition{
+				States: []StateDefinition{{Name: "Charging", Line: 2}, {Name: "Paid", Line: 3}},
+				Inputs: []InputDefinition{{Name: "Refund", Line: 5}},
+			},
+			errs:    []error{ErrUnknownType},
+			message: "line 3: type isn't registered: Paid\nline 5: type isn't registered: Refund",
+		},
+		{
+			name: "undeclared states and inputs",
+			definition: WorkflowDefinition{
+				States: []StateDefinition{{Name: "Charging"}},
+				Inputs: []InputDefinition{{Name: "Charge"}},
+				Transitions: []TransitionDefinition{
+					{From: "Charged", Input: []string{"Cancel"}, To: []string{"Declined"}, Function: "review", Line: 4},
+					{From: "Charging", Input: []string{"Cancel"}, To: []string{"Charged"}, Function: "charge", Line: 5},
+					{From: "Charging", Input: []string{"Charge"}, To: []string{"Charged"}, Function: "charge", Line: 6},
+				},
+			},
+			errs: []error{ErrUndeclaredState, ErrUndeclaredInput},
+			message: "line 4: state isn't declared: Charged\nline 5: input isn't declared: Cancel\n" +
+				"line 6: state isn't declared: Charged",
+		},
+		{
+			name: "signature mismatches",
+			definition: WorkflowDefinition{
+				States: []StateDefinition{{Name: "Charging"}, {Name: "Charged"}, {Name: "Declined"}},
+				Inputs: []InputDefinition{{Name: "Charge"}, {Name: "Cancel"}},
+				Transitions: []TransitionDefinition{
+					{From: "Charged", Input: []string{"Charge"}, To: []string{"Charged"}, Function: "charge", Line: 4},
+					{From: "Charging", Input: []string{"Cancel"}, To: []string{"Charged"}, Function: "charge", Line: 5},
+					{From: "Charging", Input: []string{"Charge", "Cancel"}, To: []string{"Charged"}, Function: "charge", Line: 6},
+					{From: "Charging", Input: []string{"Charge"}, To: []string{"Declined"}, Function: "charge", Line: 7},
+					{From: "Charging", Input: []string{"Charge"}, To: []string{"Charged", "Declined"}, Function: "charge", Line: 8},
+					{From: "Charging", Input: []string{"Charge"}, Function: "charge", Line: 9},
+					{From: "Charged", Input: []string{"Cancel"}, To: []string{"Charged"}, Function: "unknown", Line: 10},
+				},
+			},
+			errs: []error{ErrSignatureMismatch, ErrUnknownFunction},
+			message: "line 4: function signature doesn't match the declared transition: charge accepts ekstatic.stateCharging instead of ekstatic.stateCharged\n" +
+				"line 5: function signature doesn't match the declared transition: charge accepts input ekstatic.inputCharge instead of ekstatic.inputCancel\n" +
+				"line 6: function signature doesn't match the declared transition: charge accepts input ekstatic.inputCharge instead of ekstatic.inputCharge, ekstatic.inputCancel\n" +
+				"line 7: function signature doesn't match the declared transition: charge returns ekstatic.stateCharged instead of [Declined]\n" +
+				"line 8: function signature doesn't match the declared transition: charge returns ekstatic.stateCharged instead of [Charged Declined]\n" +
+				"line 9: function signature doesn't match the declared transition: charge has no declared result state\n" +
+				"line 10: function isn't registered: unknown",
+		},
+		{
+			name: "duplicate transition",
+			definition: WorkflowDefinition{
+				States: []StateDefinition{{Name: "Declined"}, {Name: "Charging"}},
+				Transitions: []TransitionDefinition{
+					{From: "Declined", To: []string{"Charging"}, Function: "settle", Line: 3},
+					{From: "Declined", To: []string{"Charging"}, Function: "settle", Line: 4},
+				},
+			},
+			errs:    []error{ErrTransitionAlreadyExists},
+			message: "line 4: there already is a transition for that state and input type",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w, err := tt.definition.Build(newChargeRegistry())
+			require.Nil(t, w)
+			for _, target := range tt.errs {
+				require.ErrorIs(t, err, target)
+			}
+			require.EqualError(t, err, tt.message)
+		})
+	}
+}
+
+func TestDefinitionError(t *testing.T) {
+	t.Parallel()
+
+	var definitionErr *DefinitionError
+
+	definition := WorkflowDefinition{States: []StateDefinition{{Name: "Paid", Line: 1}}}
+	_, err := definition.Build(newChargeRegistry())
+	require.ErrorAs(t, err, &definitionErr)
+	require.Equal(t, 1, definitionErr.Line)
+	require.ErrorIs(t, definitionErr, ErrUnknownType)
+}
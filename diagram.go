@@ -0,0 +1,174 @@
+package ekstatic
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type (
+	// DiagramOption configures the diagrams written by WriteDOT and
+	// WriteMermaid.
+	DiagramOption func(*diagramConfig)
+
+	diagramConfig struct {
+		highlighted reflect.Type
+	}
+
+	diagram struct {
+		nodes []string
+		edges []diagramEdge
+	}
+
+	diagramEdge struct {
+		from, to string
+		label    string
+		dashed   bool
+	}
+)
+
+// unknownState is the node that transitions returning an interface type
+// without declared targets point to.
+const unknownState = "?"
+
+// HighlightCurrentState highlights the type of the current state of the
+// instance in the diagram.
+func HighlightCurrentState(instance *WorkflowInstance) DiagramOption {
+	state := instance.CurrentState()
+
+	return func(c *diagramConfig) {
+		c.highlighted = reflect.TypeOf(state)
+	}
+}
+
+// WriteDOT writes a Graphviz diagram of the workflow to wr. Nodes are state
+// types and edges transitions, labelled with their input types, "ε" for
+// ε-transitions, and marked if they can fail. Transitions returning an
+// interface type have dashed edges.
+func (w *Workflow) WriteDOT(wr io.Writer, options ...DiagramOption) error {
+	config := newDiagramConfig(options)
+	d := w.diagram()
+
+	var b strings.Builder
+	b.WriteString("digraph workflow {\n")
+
+	for _, node := range d.nodes {
+		fmt.Fprintf(&b, "\t%q", node)
+		if config.isHighlighted(node) {
+			b.WriteString(" [style=filled, fillcolor=lightblue]")
+		}
+		b.WriteString(";\n")
+	}
+
+	for _, edge := range d.edges {
+		fmt.Fprintf(&b, "\t%q -> %q [label=%q", edge.from, edge.to, edge.label)
+		if edge.dashed {
+			b.WriteString(", style=dashed")
+		}
+		b.WriteString("];\n")
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(wr, b.String())
+	return err
+}
+
+// WriteMermaid writes the diagram described at WriteDOT as a Mermaid
+// flowchart to wr.
+func (w *Workflow) WriteMermaid(wr io.Writer, options ...DiagramOption) error {
+	config := newDiagramConfig(options)
+	d := w.diagram()
+
+	ids := make(map[string]string, len(d.nodes))
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	for i, node := range d.nodes {
+		ids[node] = fmt.Sprintf("s%d", i)
+		fmt.Fprintf(&b, "\t%s[%q]\n", ids[node], node)
+	}
+
+	for _, edge := range d.edges {
+		arrow := "-->"
+		if edge.dashed {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "\t%s %s|%q| %s\n", ids[edge.from], arrow, edge.label, ids[edge.to])
+	}
+
+	for _, node := range d.nodes {
+		if config.isHighlighted(node) {
+			fmt.Fprintf(&b, "\tstyle %s fill:lightblue\n", ids[node])
+		}
+	}
+
+	_, err := io.WriteString(wr, b.String())
+	return err
+}
+
+func newDiagramConfig(options []DiagramOption) diagramConfig {
+	config := diagramConfig{}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
+}
+
+func (c diagramConfig) isHighlighted(node string) bool {
+	return c.highlighted != nil && c.highlighted.String() == node
+}
+
+func (w *Workflow) diagram() diagram {
+	var (
+		d     diagram
+		nodes = make(map[string]struct{})
+	)
+
+	addNode := func(node string) {
+		if _, exists := nodes[node]; !exists {
+			nodes[node] = struct{}{}
+			d.nodes = append(d.nodes, node)
+		}
+	}
+
+	for _, descriptor := range w.Transitions() {
+		from := descriptor.State.String()
+		addNode(from)
+
+		label := "ε"
+		if !descriptor.Epsilon {
+			inputs := make([]string, len(descriptor.Inputs))
+			for i, input := range descriptor.Inputs {
+				inputs[i] = input.String()
+			}
+			label = strings.Join(inputs, ", ")
+		}
+		if descriptor.CanFail {
+			label += " (can fail)"
+		}
+
+		dashed := descriptor.Result.Kind() == reflect.Interface
+
+		targets := make([]string, len(descriptor.Results))
+		for i, result := range descriptor.Results {
+			targets[i] = result.String()
+		}
+		if len(targets) == 0 {
+			targets = []string{unknownState}
+		}
+
+		for _, to := range targets {
+			addNode(to)
+			d.edges = append(d.edges, diagramEdge{from, to, label, dashed})
+		}
+	}
+
+	slices.Sort(d.nodes)
+
+	return d
+}
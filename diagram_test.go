@@ -0,0 +1,86 @@
+package ekstatic
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func newDiagramWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(s stateCharging, i inputCharge) (stateCharged, error) { return stateCharged{}, nil },
+		func(s stateCharged) stateDeclined { return stateDeclined{} },
+		func(s stateDeclined, i inputCharge, c inputCancel) any { return s },
+	)
+
+	return w
+}
+
+func TestWorkflow_WriteDOT(t *testing.T) {
+	t.Parallel()
+
+	w := newDiagramWorkflow()
+
+	var b bytes.Buffer
+	require.NoError(t, w.WriteDOT(&b, HighlightCurrentState(w.New(stateCharged{}))))
+	require.Equal(t, `digraph workflow {
+	"?";
+	"ekstatic.stateCharged" [style=filled, fillcolor=lightblue];
+	"ekstatic.stateCharging";
+	"ekstatic.stateDeclined";
+	"ekstatic.stateCharged" -> "ekstatic.stateDeclined" [label="ε"];
+	"ekstatic.stateCharging" -> "ekstatic.stateCharged" [label="ekstatic.inputCharge (can fail)"];
+	"ekstatic.stateDeclined" -> "?" [label="ekstatic.inputCharge, ekstatic.inputCancel", style=dashed];
+}
+`, b.String())
+
+	require.EqualError(t, w.WriteDOT(failingWriter{}), "write failed")
+}
+
+func TestWorkflow_WriteMermaid(t *testing.T) {
+	t.Parallel()
+
+	w := newDiagramWorkflow()
+
+	var b bytes.Buffer
+	require.NoError(t, w.WriteMermaid(&b, HighlightCurrentState(w.New(stateCharging{}))))
+	require.Equal(t, `flowchart LR
+	s0["?"]
+	s1["ekstatic.stateCharged"]
+	s2["ekstatic.stateCharging"]
+	s3["ekstatic.stateDeclined"]
+	s1 -->|"ε"| s3
+	s2 -->|"ekstatic.inputCharge (can fail)"| s1
+	s3 -.->|"ekstatic.inputCharge, ekstatic.inputCancel"| s0
+	style s2 fill:lightblue
+`, b.String())
+
+	require.EqualError(t, w.WriteMermaid(failingWriter{}), "write failed")
+}
+
+func TestWorkflow_WriteDOT_targets(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(s stateCharging, i inputCharge) any { return s }, Targets(stateCharged{}, stateDeclined{}))
+
+	var b bytes.Buffer
+	require.NoError(t, w.WriteDOT(&b))
+	require.Equal(t, `digraph workflow {
+	"ekstatic.stateCharged";
+	"ekstatic.stateCharging";
+	"ekstatic.stateDeclined";
+	"ekstatic.stateCharging" -> "ekstatic.stateCharged" [label="ekstatic.inputCharge", style=dashed];
+	"ekstatic.stateCharging" -> "ekstatic.stateDeclined" [label="ekstatic.inputCharge", style=dashed];
+}
+`, b.String())
+}
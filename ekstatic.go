@@ -3,7 +3,9 @@ package ekstatic
 import (
 	"errors"
 	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
 type (
@@ -12,7 +14,17 @@ type (
 	TransitionSucceededAction func(newState, previousState any, input ...any)
 	TransitionFailedAction    func(err error, previousState any, input ...any)
 
+	// TransitionOption configures a transition when it is added to a
+	// Workflow.
+	TransitionOption func(*transitionConfig)
+
 	transitionIdentifer any
+
+	transitionConfig struct {
+		compensation Compensation
+		retryPolicy  *RetryPolicy
+		targets      []reflect.Type
+	}
 )
 
 var ErrTransitionNil = errors.New("transition must not be nil")
@@ -24,29 +36,98 @@ var ErrTransitionBadErrorOutput = errors.New("second return value of transition
 var ErrTransitionAlreadyExists = errors.New("there already is a transition for that state and input type")
 
 var ErrTransitionDoesNotExist = errors.New("there is no transition from the current state with the given input type")
+var ErrInstanceEvicted = errors.New("workflow instance has been evicted and must be retrieved again")
 
 type (
+	// Instance is implemented by *WorkflowInstance and by the dispatchers
+	// generated by cmd/ekstaticgen, so callers don't depend on which one
+	// they use.
+	Instance interface {
+		ContinueWith(input ...any) error
+		CurrentState() any
+	}
+
 	Workflow struct {
 		transitions           map[transitionIdentifer]Transition
 		onTransitionSucceeded func(newState, previousState any, input ...any)
+		onTransitionEmitting  EmittingSucceededAction
 		onTransitionFailed    func(err error, previousState any, input ...any)
+
+		mailboxCapacity int
+		mailboxOverflow OverflowPolicy
+
+		clock    Clock
+		timeouts map[string]timeout
+
+		timerStore       TimerStore
+		redeliveryPolicy *RetryPolicy
+		scheduler        *scheduler
+		schedulerOnce    sync.Once
+
+		idempotencyWindow int
+		onDuplicateInput  DuplicateInputAction
+
+		finalStates map[string]struct{}
+
+		transitionConfigs  map[transitionIdentifer]transitionConfig
+		compensationPolicy CompensationPolicy
+		retryPolicy        RetryPolicy
+
+		failureEdges map[string][]FailureEdge
+
+		historyCapacity int
+		historySink     HistorySink
+
+		frozen atomic.Bool
+		mu     sync.RWMutex
 	}
 
 	WorkflowInstance struct {
+		id           string
 		workflow     *Workflow
+		manager      *Manager
 		currentState any
+		evicted      bool
+		mailbox      atomic.Pointer[mailbox]
+		emitted      [][]any
+		onCommit     func()
+		timeout      *activeTimeout
+		processed    processedInputs
+		history      map[string]SubstateHistory
+		joins        []branchJoin
+		compensation []compensationStep
+		trail        historyLog
+		historyErrs  []error
 
 		mu sync.Mutex
 	}
 )
 
+var _ Instance = (*WorkflowInstance)(nil)
+
 func NewWorkflow() *Workflow {
 	return &Workflow{
 		transitions: make(map[transitionIdentifer]Transition),
 	}
 }
 
-func (w *Workflow) AddTransition(t Transition) {
+func (w *Workflow) AddTransition(t Transition, options ...TransitionOption) {
+	validateTransition(t)
+
+	w.lock()
+	defer w.mu.Unlock()
+
+	identifier := identifierFromTransition(t)
+
+	if _, transitionExists := w.transitions[identifier]; transitionExists {
+		panic(ErrTransitionAlreadyExists)
+	}
+
+	w.transitions[identifier] = t
+	w.configureTransition(identifier, options)
+}
+
+func validateTransition(t Transition) {
 	if t == nil {
 		panic(ErrTransitionNil)
 	}
@@ -68,14 +149,31 @@ func (w *Workflow) AddTransition(t Transition) {
 	case transitionType.NumOut() == 2 && transitionType.Out(1) != reflect.TypeFor[error]():
 		panic(ErrTransitionBadErrorOutput)
 	}
+}
 
-	identifier := identifierFromTransition(t)
+func (w *Workflow) transitionConfig(identifier transitionIdentifer) (transitionConfig, bool) {
+	defer w.rlock()()
 
-	if _, transitionExists := w.transitions[identifier]; transitionExists {
-		panic(ErrTransitionAlreadyExists)
+	config, exists := w.transitionConfigs[identifier]
+	return config, exists
+}
+
+func (w *Workflow) configureTransition(identifier transitionIdentifer, options []TransitionOption) {
+	delete(w.transitionConfigs, identifier)
+
+	if len(options) == 0 {
+		return
 	}
 
-	w.transitions[identifier] = t
+	config := transitionConfig{}
+	for _, option := range options {
+		option(&config)
+	}
+
+	if w.transitionConfigs == nil {
+		w.transitionConfigs = make(map[transitionIdentifer]transitionConfig)
+	}
+	w.transitionConfigs[identifier] = config
 }
 
 func (w *Workflow) AddTransitions(transitions ...Transition) {
@@ -85,14 +183,30 @@ func (w *Workflow) AddTransitions(transitions ...Transition) {
 }
 
 func (w *Workflow) AddTransitionSucceededAction(onStateUpdated TransitionSucceededAction) {
+	w.lock()
+	defer w.mu.Unlock()
+
 	w.onTransitionSucceeded = onStateUpdated
 }
 
 func (w *Workflow) AddTransitionFailedAction(onTransitionFailed TransitionFailedAction) {
+	w.lock()
+	defer w.mu.Unlock()
+
 	w.onTransitionFailed = onTransitionFailed
 }
 
 func (w *Workflow) New(initialState any) *WorkflowInstance {
+	instance := w.newInstance(initialState)
+
+	if t, exists := w.timeoutFor(initialState); exists {
+		instance.startTimeout(w.getClock().Now().Add(t.duration))
+	}
+
+	return instance
+}
+
+func (w *Workflow) newInstance(initialState any) *WorkflowInstance {
 	if initialState == nil {
 		panic("initial state must not be nil")
 	}
@@ -110,65 +224,151 @@ func (w *WorkflowInstance) ContinueWith(input ...any) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	return w.continueWith(input...)
+	if w.evicted {
+		return ErrInstanceEvicted
+	}
+
+	return w.withHistoryErrors(w.continueWithKey(input...))
 }
 
 func (w *WorkflowInstance) continueWith(input ...any) error {
 
 	// Select transition
 
-	identifier := identifierFromArguments(w.currentState, input...)
-
-	if _, exists := w.workflow.transitions[identifier]; !exists {
+	identifier, transitionState, exists := w.workflow.resolveTransition(w.currentState, input...)
+	if !exists {
+		if sub, hasSubmachine := w.currentState.(submachine); hasSubmachine {
+			return w.forwardTo(sub, input...)
+		}
 		return ErrTransitionDoesNotExist
 	}
 
 	// Perform transition
 
-	transition := reflect.ValueOf(w.workflow.transitions[identifier])
+	t, _ := w.workflow.transition(identifier)
+	transition := reflect.ValueOf(t)
 
-	transitionArgs := make([]reflect.Value, 1+len(input))
-	transitionArgs[0] = reflect.ValueOf(w.currentState)
+	transitionArgs := make([]reflect.Value, 1+len(input), 2+len(input))
+	transitionArgs[0] = reflect.ValueOf(transitionState)
 	for i, inputArg := range input {
 		transitionArgs[i+1] = reflect.ValueOf(inputArg)
 	}
 
-	transitionResult := transition.Call(transitionArgs)
+	if acceptsEmitter(transition.Type()) {
+		e := &emitter{instance: w, active: true}
+		defer e.deactivate()
+		transitionArgs = append(transitionArgs, reflect.ValueOf(e))
+	}
 
-	if transitionResult[0].Interface() == nil {
-		panic("transition returned nil as result state")
+	start := w.workflow.getClock().Now()
+	transitionResult, err := w.call(identifier, transition, transitionArgs)
+
+	record := func(err error) {}
+	if w.workflow.recordsHistory() {
+		entry := HistoryEntry{
+			Time:          start,
+			PreviousState: w.currentState,
+			Input:         slices.Clone(input),
+			Duration:      w.workflow.getClock().Now().Sub(start),
+			Epsilon:       len(input) == 0,
+		}
+		record = func(err error) {
+			entry.Err = err
+			w.recordEntry(entry)
+		}
 	}
 
 	// Perform failure action
 
-	if len(transitionResult) == 2 && transitionResult[1].Interface() != nil {
-		err := transitionResult[1].Interface().(error)
+	if err != nil {
 		if w.workflow.onTransitionFailed != nil {
 			w.workflow.onTransitionFailed(err, w.currentState, input...)
 		}
+		if errorState, exists := w.workflow.failureTarget(w.currentState, err); exists {
+			previousState := w.currentState
+			w.currentState = errorState
+			w.enterState(previousState)
+			record(err)
+			w.commit()
+			return w.succeed(previousState, input...)
+		}
+		record(err)
+		if w.workflow.compensationPolicy == CompensateOnFailure {
+			if compensationErr := w.compensate(); compensationErr != nil {
+				return errors.Join(err, compensationErr)
+			}
+		}
 		return err
 	}
 
-	// Perform success action & assign state
+	// Start forked branches
 
-	if w.workflow.onTransitionSucceeded != nil {
-		previousState := w.currentState
-		w.currentState = transitionResult[0].Interface()
-		w.workflow.onTransitionSucceeded(w.currentState, previousState, input...)
-	} else {
-		w.currentState = transitionResult[0].Interface()
+	if branches, isFork := transitionResult[0].Interface().(Fork); isFork {
+		w.fork(branches)
+		record(nil)
+		w.commit()
+		w.performSucceededActions(w.currentState, input...)
+		return nil
 	}
 
+	// Assign state & perform success action
+
+	previousState := w.currentState
+	w.currentState = transitionResult[0].Interface()
+	if history, isHistory := w.currentState.(HistoryState); isHistory {
+		w.currentState = w.restoreHistory(history)
+	}
+	w.enterState(previousState)
+	w.recordCompensation(identifier, previousState, input)
+	record(nil)
+	w.commit()
+
+	return w.succeed(previousState, input...)
+}
+
+// commit runs the commit action of the input being applied once its
+// transition has changed the state, before ε-transitions and emitted inputs
+// follow.
+func (w *WorkflowInstance) commit() {
+	if w.onCommit != nil {
+		w.onCommit()
+		w.onCommit = nil
+	}
+}
+
+// succeed performs the success action for a transition into the current
+// state and chains its ε-transition.
+func (w *WorkflowInstance) succeed(previousState any, input ...any) error {
+	w.performSucceededActions(previousState, input...)
+
 	// Chain ε-transition
 
-	identifier = identifierFromArguments(w.currentState)
-	if _, exists := w.workflow.transitions[identifier]; exists {
+	if _, exists := w.workflow.transition(identifierFromArguments(w.currentState)); exists {
 		return w.continueWith()
 	}
 
 	return nil
 }
 
+func (w *Workflow) transition(identifier transitionIdentifer) (Transition, bool) {
+	defer w.rlock()()
+
+	t, exists := w.transitions[identifier]
+	return t, exists
+}
+
+func (w *WorkflowInstance) enterState(previousState any) {
+	w.recordHistory(previousState)
+	w.updateTimeout(previousState)
+	w.notifyJoins()
+}
+
+// ID returns the identifier the instance is managed under, or an
+// empty string if it wasn't created by a Manager.
+func (w *WorkflowInstance) ID() string {
+	return w.id
+}
+
 func (w *WorkflowInstance) CurrentState() any {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -178,8 +378,13 @@ func (w *WorkflowInstance) CurrentState() any {
 
 func identifierFromTransition(t Transition) transitionIdentifer {
 	transitionType := reflect.TypeOf(t)
+	numIn := transitionType.NumIn()
+	if acceptsEmitter(transitionType) {
+		numIn--
+	}
+
 	transitionIdentifier := ""
-	for i := 0; i < transitionType.NumIn(); i++ {
+	for i := 0; i < numIn; i++ {
 		transitionIdentifier += transitionType.In(i).String()
 	}
 
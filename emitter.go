@@ -0,0 +1,107 @@
+package ekstatic
+
+import (
+	"reflect"
+	"sync"
+)
+
+type (
+	// Emitter lets a transition submit follow-up inputs to its own instance.
+	// A transition receives an Emitter if its last parameter is of that type.
+	// Inputs emitted while the transition runs are applied in order once it
+	// has been committed, before ContinueWith returns. Inputs emitted later,
+	// e.g. from a goroutine started by the transition, are sent to the
+	// mailbox of the instance instead, and Emit returns the error of Send.
+	Emitter interface {
+		Emit(input ...any) error
+	}
+
+	// EmittingSucceededAction is performed after the TransitionSucceededAction
+	// and receives an Emitter for the instance, which queues inputs like the
+	// Emitter of a transition. Actions must use it instead of calling
+	// ContinueWith or Send on the instance, which waits for the transition
+	// the action is part of.
+	EmittingSucceededAction func(e Emitter, newState, previousState any, input ...any)
+
+	emitter struct {
+		instance *WorkflowInstance
+		active   bool
+
+		mu sync.Mutex
+	}
+)
+
+var emitterType = reflect.TypeFor[Emitter]()
+
+func (w *Workflow) AddEmittingSucceededAction(onTransitionEmitting EmittingSucceededAction) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.onTransitionEmitting = onTransitionEmitting
+}
+
+func (e *emitter) Emit(input ...any) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.active {
+		return e.instance.Send(input...)
+	}
+
+	e.instance.emitted = append(e.instance.emitted, input)
+
+	return nil
+}
+
+func (e *emitter) deactivate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.active = false
+}
+
+// performSucceededActions performs the success actions for a transition into
+// the current state.
+func (w *WorkflowInstance) performSucceededActions(previousState any, input ...any) {
+	if w.workflow.onTransitionSucceeded != nil {
+		w.workflow.onTransitionSucceeded(w.currentState, previousState, input...)
+	}
+
+	if w.workflow.onTransitionEmitting != nil {
+		e := &emitter{instance: w, active: true}
+		defer e.deactivate()
+		w.workflow.onTransitionEmitting(e, w.currentState, previousState, input...)
+	}
+}
+
+func acceptsEmitter(transitionType reflect.Type) bool {
+	return transitionType.NumIn() > 1 && transitionType.In(transitionType.NumIn()-1) == emitterType
+}
+
+// continueWithEmitted applies the input and afterwards all inputs emitted by
+// the transitions involved. If any of them fails, the inputs still queued
+// are discarded.
+func (w *WorkflowInstance) continueWithEmitted(input ...any) error {
+	return w.applyEmitted(w.continueWith(input...))
+}
+
+// applyEmitted applies the inputs emitted so far if err is nil, and
+// discards them otherwise.
+func (w *WorkflowInstance) applyEmitted(err error) error {
+	defer func() { w.emitted = nil }()
+
+	if err != nil {
+		return err
+	}
+
+	for len(w.emitted) > 0 {
+		next := w.emitted[0]
+		w.emitted = w.emitted[1:]
+
+		if err := w.continueWith(next...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowInstance_ContinueWith_emitter(t *testing.T) {
+	t.Parallel()
+
+	type (
+		stateStarted  string
+		stateStepped  string
+		stateFinished string
+
+		inputStart  struct{}
+		inputStep   string
+		inputFinish struct{}
+	)
+
+	testcases := []struct {
+		name             string
+		transitions      []Transition
+		destinationState any
+		err              error
+	}{
+		{
+			name: "emitted inputs are applied in order after the transition",
+			transitions: []Transition{
+				func(s stateStarted, i inputStart, e Emitter) stateStepped {
+					e.Emit(inputStep("a"))
+					e.Emit(inputStep("b"))
+					e.Emit(inputFinish{})
+					return stateStepped(s)
+				},
+				func(s stateStepped, i inputStep) stateStepped { return s + stateStepped(i) },
+				func(s stateStepped, i inputFinish) stateFinished { return stateFinished(s) },
+			},
+			destinationState: stateFinished("ab"),
+		},
+		{
+			name: "ε-transition with emitter",
+			transitions: []Transition{
+				func(s stateStarted, i inputStart) stateStepped { return stateStepped(s) },
+				func(s stateStepped, e Emitter) stateFinished {
+					e.Emit(inputStep("c"))
+					return stateFinished(s)
+				},
+				func(s stateFinished, i inputStep) stateFinished { return s + stateFinished(i) },
+			},
+			destinationState: stateFinished("c"),
+		},
+		{
+			name: "emitted inputs are discarded if the transition fails",
+			transitions: []Transition{
+				func(s stateStarted, i inputStart, e Emitter) (stateStepped, error) {
+					e.Emit(inputStep("a"))
+					return "", errors.New("failed emitting")
+				},
+				func(s stateStepped, i inputStep) stateStepped { return s + stateStepped(i) },
+			},
+			destinationState: stateStarted(""),
+			err:              errors.New("failed emitting"),
+		},
+		{
+			name: "emitted input without transition",
+			transitions: []Transition{
+				func(s stateStarted, i inputStart, e Emitter) stateStepped {
+					e.Emit(inputFinish{}, inputFinish{})
+					return stateStepped(s)
+				},
+			},
+			destinationState: stateStepped(""),
+			err:              ErrTransitionDoesNotExist,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w := NewWorkflow()
+			w.AddTransitions(tt.transitions...)
+
+			instance := w.New(stateStarted(""))
+			err := instance.ContinueWith(inputStart{})
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+			require.Empty(t, instance.emitted)
+		})
+	}
+}
+
+func TestEmitter_Emit_afterTransition(t *testing.T) {
+	t.Parallel()
+
+	emitters := make(chan Emitter, 1)
+
+	w := NewWorkflow()
+	w.AddTransition(func(state string, input string, e Emitter) string {
+		emitters <- e
+		return state + input
+	})
+
+	instance := w.New("")
+	require.NoError(t, instance.ContinueWith("a"))
+
+	(<-emitters).Emit("b")
+	require.Eventually(t, func() bool { return instance.CurrentState() == "ab" }, waitFor, tick)
+}
+
+func TestEmitter_Emit_mailboxFull(t *testing.T) {
+	t.Parallel()
+
+	emitters := make(chan Emitter, 1)
+	started, release := make(chan struct{}, 2), make(chan struct{})
+
+	w := NewWorkflow()
+	w.SetMailbox(1, OverflowReject)
+	w.AddTransitions(
+		func(state string, input string, e Emitter) string {
+			emitters <- e
+			return state + input
+		},
+		func(state string, input int) string {
+			started <- struct{}{}
+			<-release
+			return state
+		},
+	)
+
+	instance := w.New("")
+	require.NoError(t, instance.ContinueWith("a"))
+	emitter := <-emitters
+
+	// Fill the mailbox while the instance is busy with the first input
+	require.NoError(t, instance.Send(1))
+	<-started
+	require.NoError(t, instance.Send(2))
+
+	require.ErrorIs(t, emitter.Emit("b"), ErrMailboxFull)
+
+	close(release)
+	require.Eventually(t, func() bool { return emitter.Emit("b") == nil }, waitFor, tick)
+	require.Eventually(t, func() bool { return instance.CurrentState() == "ab" }, waitFor, tick)
+}
+
+func TestWorkflow_AddEmittingSucceededAction(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.SetMailbox(1, OverflowBlock)
+	w.AddTransition(func(state int, input string) int { return state + len(input) })
+
+	emitters := make(chan Emitter, 1)
+	w.AddEmittingSucceededAction(func(e Emitter, newState, previousState any, input ...any) {
+		require.Equal(t, previousState.(int)+1, newState)
+		if newState.(int)%2 == 1 {
+			require.NoError(t, e.Emit("x"))
+		}
+		select {
+		case emitters <- e:
+		default:
+		}
+	})
+
+	instance := w.New(0)
+	require.NoError(t, instance.ContinueWith("x"))
+	require.Equal(t, 2, instance.CurrentState())
+
+	// Inputs emitted while the mailbox worker applies an input don't need room
+	// in the mailbox
+	state, err := instance.SendAndWait("x").Wait()
+	require.NoError(t, err)
+	require.Equal(t, 4, state)
+
+	require.NoError(t, (<-emitters).Emit("x"))
+	require.Eventually(t, func() bool { return instance.CurrentState() == 6 }, waitFor, tick)
+}
+
+func TestWorkflow_AddTransition_emitter(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(state string, input string, e Emitter) string { return state })
+
+	require.PanicsWithError(t, ErrTransitionAlreadyExists.Error(), func() {
+		w.AddTransition(func(state string, input string) string { return state })
+	})
+}
@@ -0,0 +1,42 @@
+package examples
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/metamogul/ekstatic"
+)
+
+type (
+	stateCartOpen    emptyState
+	stateCheckout    emptyState
+	stateGiftWrapped emptyState
+)
+
+type (
+	triggerCheckout emptyInput
+	triggerGiftWrap emptyInput
+)
+
+func ExampleWorkflow_Clone() {
+	shopWorkflow := ekstatic.NewWorkflow()
+	shopWorkflow.AddTransitions(
+		func(stateCartOpen, triggerCheckout) stateCheckout { return stateCheckout{} },
+	)
+
+	giftShopWorkflow := shopWorkflow.Clone()
+	giftShopWorkflow.AddTransition(
+		func(stateCartOpen, triggerGiftWrap) stateGiftWrapped { return stateGiftWrapped{} },
+	)
+
+	shop := shopWorkflow.New(stateCartOpen{})
+	fmt.Println(shop.ContinueWith(triggerGiftWrap{}))
+
+	giftShop := giftShopWorkflow.New(stateCartOpen{})
+	_ = giftShop.ContinueWith(triggerGiftWrap{})
+	fmt.Println(reflect.TypeOf(giftShop.CurrentState()).Name())
+
+	// Output:
+	// there is no transition from the current state with the given input type
+	// stateGiftWrapped
+}
@@ -23,14 +23,14 @@ func ExampleWorkflow_random() {
 		} else {
 			return state2{}
 		}
-	})
+	}, ekstatic.Targets(state1{}, state2{}))
 	randomizedWorkflow.AddTransition(func(s state2, t triggerRandom) any {
 		if random.IntN(2) == 0 {
 			return state1{}
 		} else {
 			return state2{}
 		}
-	})
+	}, ekstatic.Targets(state1{}, state2{}))
 
 	randomizer := randomizedWorkflow.New(state1{})
 
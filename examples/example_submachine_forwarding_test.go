@@ -0,0 +1,80 @@
+package examples
+
+import (
+	"fmt"
+
+	"github.com/metamogul/ekstatic"
+)
+
+// Order workflow:
+
+type (
+	orderPlaced      emptyState
+	orderAwaitingPay struct {
+		*ekstatic.WorkflowInstance
+	}
+	orderPaid struct {
+		amount int
+	}
+)
+
+type orderCheckout emptyInput
+
+// Payment workflow:
+
+type (
+	paymentOpen     emptyState
+	paymentCardRead struct {
+		card string
+	}
+	paymentSettled struct {
+		amount int
+	}
+)
+
+type (
+	paymentCardInserted string
+	paymentPinEntered   int
+)
+
+func ExampleWorkflow_submachine_forwarding() {
+	paymentWorkflow := ekstatic.NewWorkflow()
+	paymentWorkflow.AddTransitions(
+		func(s paymentOpen, c paymentCardInserted) paymentCardRead {
+			fmt.Printf("[Payment] card %s inserted\n", c)
+			return paymentCardRead{string(c)}
+		},
+		func(s paymentCardRead, p paymentPinEntered) paymentSettled {
+			fmt.Printf("[Payment] pin entered for card %s\n", s.card)
+			return paymentSettled{42}
+		},
+	)
+	paymentWorkflow.AddFinalStates(paymentSettled{})
+
+	orderWorkflow := ekstatic.NewWorkflow()
+	orderWorkflow.AddTransitions(
+		func(s orderPlaced, c orderCheckout) orderAwaitingPay {
+			fmt.Println("[Order] checking out")
+			return orderAwaitingPay{paymentWorkflow.New(paymentOpen{})}
+		},
+		func(s orderAwaitingPay, p paymentSettled) orderPaid {
+			fmt.Printf("[Order] paid %d\n", p.amount)
+			return orderPaid{p.amount}
+		},
+	)
+
+	order := orderWorkflow.New(orderPlaced{})
+
+	_ = order.ContinueWith(orderCheckout{})
+	_ = order.ContinueWith(paymentCardInserted("1234"))
+	_ = order.ContinueWith(paymentPinEntered(1111))
+
+	fmt.Printf("%T%v\n", order.CurrentState(), order.CurrentState())
+
+	// Output:
+	// [Order] checking out
+	// [Payment] card 1234 inserted
+	// [Payment] pin entered for card 1234
+	// [Order] paid 42
+	// examples.orderPaid{42}
+}
@@ -0,0 +1,53 @@
+package examples
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/metamogul/ekstatic"
+)
+
+type (
+	stateDoorClosed emptyState
+	stateDoorOpen   emptyState
+	stateAlarm      emptyState
+)
+
+type (
+	triggerOpenDoor  emptyInput
+	triggerCloseDoor emptyInput
+	triggerDoorAlarm emptyInput
+)
+
+func ExampleWorkflow_timeout() {
+	clock := ekstatic.NewManualClock(time.Date(2024, time.April, 1, 12, 0, 0, 0, time.UTC))
+
+	doorWorkflow := ekstatic.NewWorkflow()
+	doorWorkflow.SetClock(clock)
+	doorWorkflow.AddTransitions(
+		func(stateDoorClosed, triggerOpenDoor) stateDoorOpen { return stateDoorOpen{} },
+		func(stateDoorOpen, triggerCloseDoor) stateDoorClosed { return stateDoorClosed{} },
+		func(stateDoorOpen, triggerDoorAlarm) stateAlarm { return stateAlarm{} },
+	)
+	doorWorkflow.AddTimeout(stateDoorOpen{}, time.Minute, func() any { return triggerDoorAlarm{} })
+
+	door := doorWorkflow.New(stateDoorClosed{})
+
+	_ = door.ContinueWith(triggerOpenDoor{})
+	clock.Advance(30 * time.Second)
+	fmt.Println(reflect.TypeOf(door.CurrentState()).Name())
+
+	_ = door.ContinueWith(triggerCloseDoor{})
+	clock.Advance(time.Minute)
+	fmt.Println(reflect.TypeOf(door.CurrentState()).Name())
+
+	_ = door.ContinueWith(triggerOpenDoor{})
+	clock.Advance(time.Minute)
+	fmt.Println(reflect.TypeOf(door.CurrentState()).Name())
+
+	// Output:
+	// stateDoorOpen
+	// stateDoorClosed
+	// stateAlarm
+}
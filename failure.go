@@ -0,0 +1,79 @@
+package ekstatic
+
+import (
+	"errors"
+	"reflect"
+)
+
+// FailureEdge moves an instance into an error state when a transition from
+// a state fails with a certain type of error. It is created with OnError.
+type FailureEdge struct {
+	from    any
+	to      any
+	matches func(err error) bool
+}
+
+// OnError returns a FailureEdge from the type of the from state to the to
+// state for errors that errors.As can assign to E. When a transition from
+// a state of that type, or a substate of it, fails with such an error after
+// all retries, the failure action is performed and the instance moves to the
+// to state as if the transition had returned it. ContinueWith then returns
+// nil and no compensations are run.
+func OnError[E error](from, to any) FailureEdge {
+	if from == nil {
+		panic("failure edge from state must not be nil")
+	}
+	if to == nil {
+		panic("failure edge to state must not be nil")
+	}
+
+	return FailureEdge{
+		from: from,
+		to:   to,
+		matches: func(err error) bool {
+			var target E
+			return errors.As(err, &target)
+		},
+	}
+}
+
+// AddFailureEdges adds the failure edges to the workflow. If several edges
+// match a failed transition, the one added first wins, and edges of a
+// substate win over those of its parents.
+func (w *Workflow) AddFailureEdges(edges ...FailureEdge) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	if w.failureEdges == nil {
+		w.failureEdges = make(map[string][]FailureEdge)
+	}
+
+	for _, edge := range edges {
+		if edge.matches == nil {
+			panic("failure edge must be created with OnError")
+		}
+
+		from := reflect.TypeOf(edge.from).String()
+		w.failureEdges[from] = append(w.failureEdges[from], edge)
+	}
+}
+
+// failureTarget returns the state an instance in the given state moves to
+// after a transition failed with err.
+func (w *Workflow) failureTarget(state any, err error) (any, bool) {
+	defer w.rlock()()
+
+	if len(w.failureEdges) == 0 {
+		return nil, false
+	}
+
+	for _, s := range append([]any{state}, ancestors(state)...) {
+		for _, edge := range w.failureEdges[reflect.TypeOf(s).String()] {
+			if edge.matches(err) {
+				return edge.to, true
+			}
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,181 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateCharging        struct{}
+	stateChargingExpress struct{}
+	stateCharged         struct{}
+	stateDeclined        struct{}
+	stateChargeFailed    struct{}
+
+	inputCharge int
+
+	paymentDeclinedError struct {
+		reason string
+	}
+)
+
+func (stateChargingExpress) ParentState() any { return stateCharging{} }
+
+func (e *paymentDeclinedError) Error() string {
+	return "payment declined: " + e.reason
+}
+
+func newChargeWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(s stateCharging, amount inputCharge) (stateCharged, error) {
+			switch {
+			case amount < 0:
+				return stateCharged{}, errors.New("invalid amount")
+			case amount > 100:
+				return stateCharged{}, fmt.Errorf("charging: %w", &paymentDeclinedError{"limit exceeded"})
+			}
+			return stateCharged{}, nil
+		},
+	)
+
+	return w
+}
+
+func TestOnError(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithValue(t, "failure edge from state must not be nil", func() {
+		OnError[*paymentDeclinedError](nil, stateDeclined{})
+	})
+	require.PanicsWithValue(t, "failure edge to state must not be nil", func() {
+		OnError[*paymentDeclinedError](stateCharging{}, nil)
+	})
+	require.PanicsWithValue(t, "failure edge must be created with OnError", func() {
+		NewWorkflow().AddFailureEdges(FailureEdge{})
+	})
+}
+
+func TestWorkflowInstance_ContinueWith_failureEdge(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		edges            []FailureEdge
+		initialState     any
+		input            inputCharge
+		err              error
+		destinationState any
+	}{
+		{
+			name:             "transition succeeds",
+			edges:            []FailureEdge{OnError[*paymentDeclinedError](stateCharging{}, stateDeclined{})},
+			initialState:     stateCharging{},
+			input:            10,
+			destinationState: stateCharged{},
+		},
+		{
+			name:             "wrapped error matches",
+			edges:            []FailureEdge{OnError[*paymentDeclinedError](stateCharging{}, stateDeclined{})},
+			initialState:     stateCharging{},
+			input:            200,
+			destinationState: stateDeclined{},
+		},
+		{
+			name:             "error doesn't match",
+			edges:            []FailureEdge{OnError[*paymentDeclinedError](stateCharging{}, stateDeclined{})},
+			initialState:     stateCharging{},
+			input:            -1,
+			err:              errors.New("invalid amount"),
+			destinationState: stateCharging{},
+		},
+		{
+			name: "first matching edge wins",
+			edges: []FailureEdge{
+				OnError[*paymentDeclinedError](stateCharging{}, stateDeclined{}),
+				OnError[error](stateCharging{}, stateChargeFailed{}),
+			},
+			initialState:     stateCharging{},
+			input:            200,
+			destinationState: stateDeclined{},
+		},
+		{
+			name:             "edge of parent state",
+			edges:            []FailureEdge{OnError[error](stateCharging{}, stateChargeFailed{})},
+			initialState:     stateChargingExpress{},
+			input:            -1,
+			destinationState: stateChargeFailed{},
+		},
+		{
+			name: "edge of substate wins",
+			edges: []FailureEdge{
+				OnError[error](stateCharging{}, stateChargeFailed{}),
+				OnError[error](stateChargingExpress{}, stateDeclined{}),
+			},
+			initialState:     stateChargingExpress{},
+			input:            -1,
+			destinationState: stateDeclined{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w := newChargeWorkflow()
+			w.AddFailureEdges(tt.edges...)
+
+			var actions []string
+			w.AddTransitionFailedAction(func(err error, previousState any, input ...any) {
+				actions = append(actions, "failed")
+			})
+			w.AddTransitionSucceededAction(func(newState, previousState any, input ...any) {
+				actions = append(actions, "succeeded")
+				require.Equal(t, tt.initialState, previousState)
+			})
+
+			instance := w.New(tt.initialState)
+			err := instance.ContinueWith(tt.input)
+
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+
+			switch {
+			case tt.err != nil:
+				require.Equal(t, []string{"failed"}, actions)
+			case tt.destinationState == stateCharged{}:
+				require.Equal(t, []string{"succeeded"}, actions)
+			default:
+				require.Equal(t, []string{"failed", "succeeded"}, actions)
+			}
+		})
+	}
+}
+
+func TestWorkflowInstance_ContinueWith_failureEdgeSkipsCompensation(t *testing.T) {
+	t.Parallel()
+
+	compensated := false
+
+	w := newChargeWorkflow()
+	w.AddTransition(
+		func(s stateDeclined, amount inputCharge) stateCharging { return stateCharging{} },
+		WithCompensation(func(newState, previousState any, input ...any) error {
+			compensated = true
+			return nil
+		}),
+	)
+	w.AddFailureEdges(OnError[*paymentDeclinedError](stateCharging{}, stateDeclined{}))
+	w.SetCompensationPolicy(CompensateOnFailure)
+
+	instance := w.New(stateDeclined{})
+	require.NoError(t, instance.ContinueWith(inputCharge(0)))
+	require.NoError(t, instance.ContinueWith(inputCharge(200)))
+
+	require.Equal(t, stateDeclined{}, instance.CurrentState())
+	require.False(t, compensated)
+}
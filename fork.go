@@ -0,0 +1,174 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrBranchFailed = errors.New("forked branch failed")
+
+type (
+	// Fork can be returned by a transition to start parallel branches. The
+	// instance remains in its current state, and every branch that has an
+	// ε-transition for its current state is started on its own goroutine.
+	// Once all branches have reached a final state, Join is applied to the
+	// instance. If there is no transition for Join, or the instance can't
+	// be retrieved from its Manager, the error is passed to the failure
+	// action along with the current state of the instance, or nil if it
+	// couldn't be retrieved. If the ε-transition a branch is started with
+	// fails, the error is passed to the failure action as well, wrapped in
+	// ErrBranchFailed and with the branch as input. The join then waits
+	// until the branch has been continued to a final state otherwise.
+	//
+	// Pending joins aren't part of the Snapshot of a branch, so branches
+	// must not be evicted before they have reached a final state.
+	Fork []*WorkflowInstance
+
+	// Join holds the final states of the branches of a Fork, in the order
+	// the branches were forked.
+	Join struct {
+		Results []any
+	}
+
+	branchJoin struct {
+		join  *join
+		index int
+	}
+
+	join struct {
+		parent    *WorkflowInstance
+		results   []any
+		remaining int
+
+		mu sync.Mutex
+	}
+)
+
+func (w *WorkflowInstance) fork(branches Fork) {
+	for _, branch := range branches {
+		if branch == nil {
+			panic("forked branch must not be nil")
+		}
+	}
+
+	j := &join{
+		parent:    w,
+		results:   make([]any, len(branches)),
+		remaining: len(branches),
+	}
+
+	if len(branches) == 0 {
+		go w.deliverJoin(Join{})
+		return
+	}
+
+	for i, branch := range branches {
+		branch.mu.Lock()
+		branch.joins = append(branch.joins, branchJoin{j, i})
+		branch.notifyJoins()
+		branch.mu.Unlock()
+
+		go branch.start()
+	}
+}
+
+// start performs the ε-transition of the current state of the branch, if
+// there is one.
+func (w *WorkflowInstance) start() {
+	w.mu.Lock()
+
+	var err error
+	joins := w.joins
+	if _, exists := w.workflow.transition(identifierFromArguments(w.currentState)); exists {
+		err = w.continueWithEmitted()
+		_ = w.withHistoryErrors(nil)
+	}
+
+	w.mu.Unlock()
+
+	if err == nil {
+		return
+	}
+
+	for _, bj := range joins {
+		bj.join.parent.reportBranchFailure(w, err)
+	}
+}
+
+// reportBranchFailure passes the error of the branch to the failure action
+// along with the current state of the instance, retrieving it from its
+// Manager if it is managed.
+func (w *WorkflowInstance) reportBranchFailure(branch *WorkflowInstance, err error) {
+	if w.workflow.onTransitionFailed == nil {
+		return
+	}
+
+	err = fmt.Errorf("%w: %w", ErrBranchFailed, err)
+
+	instance := w
+	if w.manager != nil {
+		var getErr error
+		if instance, getErr = w.manager.Get(w.id); getErr != nil {
+			w.workflow.onTransitionFailed(errors.Join(err, getErr), nil, branch)
+			return
+		}
+	}
+
+	w.workflow.onTransitionFailed(err, instance.CurrentState(), branch)
+}
+
+// notifyJoins reports the state of the instance to the joins it is a branch
+// of, once it is final.
+func (w *WorkflowInstance) notifyJoins() {
+	if len(w.joins) == 0 || !w.workflow.isFinal(w.currentState) {
+		return
+	}
+
+	for _, bj := range w.joins {
+		bj.join.branchCompleted(bj.index, w.currentState)
+	}
+
+	w.joins = nil
+}
+
+func (j *join) branchCompleted(index int, finalState any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.results[index] = finalState
+	j.remaining--
+
+	if j.remaining == 0 {
+		go j.parent.deliverJoin(Join{j.results})
+	}
+}
+
+// deliverJoin applies the join to the instance and passes errors to the
+// failure action that didn't occur in the join transition itself, which
+// reports them already.
+func (w *WorkflowInstance) deliverJoin(join Join) {
+	for {
+		instance, err := w, error(nil)
+		if w.manager != nil {
+			instance, err = w.manager.Get(w.id)
+		}
+
+		var currentState any
+		if err == nil {
+			err = instance.ContinueWith(join)
+			if errors.Is(err, ErrInstanceEvicted) && w.manager != nil {
+				continue
+			}
+			if !errors.Is(err, ErrTransitionDoesNotExist) {
+				return
+			}
+			currentState = instance.CurrentState()
+		}
+
+		if w.workflow.onTransitionFailed != nil {
+			w.workflow.onTransitionFailed(err, currentState, join)
+		}
+		return
+	}
+}
@@ -0,0 +1,161 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateBookingStarted struct{}
+	stateBookingPending struct{}
+	stateBooked         []any
+
+	stateFlightRequested struct{}
+	stateFlightBooked    string
+	stateHotelRequested  struct{}
+	stateHotelBooked     string
+
+	inputBook         struct{}
+	inputHotelConfirm string
+)
+
+func newBookingWorkflows() (booking, flight, hotel *Workflow) {
+	flight = NewWorkflow()
+	flight.AddTransition(func(stateFlightRequested) stateFlightBooked { return "LH123" })
+	flight.AddFinalStates(stateFlightBooked(""))
+
+	hotel = NewWorkflow()
+	hotel.AddTransition(func(s stateHotelRequested, i inputHotelConfirm) stateHotelBooked { return stateHotelBooked(i) })
+	hotel.AddFinalStates(stateHotelBooked(""))
+
+	booking = NewWorkflow()
+
+	return booking, flight, hotel
+}
+
+func TestWorkflowInstance_ContinueWith_fork(t *testing.T) {
+	t.Parallel()
+
+	booking, flight, hotel := newBookingWorkflows()
+
+	flightBranch := flight.New(stateFlightRequested{})
+	hotelBranch := hotel.New(stateHotelRequested{})
+
+	joined := make(chan any, 1)
+	booking.AddTransitions(
+		func(stateBookingStarted, inputBook) Fork { return Fork{flightBranch, hotelBranch} },
+		func(s stateBookingStarted, j Join) stateBooked { return j.Results },
+	)
+	booking.AddTransitionSucceededAction(func(newState, previousState any, input ...any) {
+		if _, isJoin := input[0].(Join); isJoin {
+			joined <- newState
+		}
+	})
+
+	instance := booking.New(stateBookingStarted{})
+	require.NoError(t, instance.ContinueWith(inputBook{}))
+	require.Equal(t, stateBookingStarted{}, instance.CurrentState())
+
+	require.Eventually(t, flightBranch.IsFinal, waitFor, tick)
+	require.Never(t, func() bool { return len(joined) > 0 }, 20*tick, tick)
+
+	require.NoError(t, hotelBranch.ContinueWith(inputHotelConfirm("Ritz")))
+
+	require.Equal(t, stateBooked{stateFlightBooked("LH123"), stateHotelBooked("Ritz")}, <-joined)
+	require.Equal(t, stateBooked{stateFlightBooked("LH123"), stateHotelBooked("Ritz")}, instance.CurrentState())
+}
+
+func TestWorkflowInstance_ContinueWith_forkCompleted(t *testing.T) {
+	t.Parallel()
+
+	booking, _, hotel := newBookingWorkflows()
+
+	completed := hotel.New(stateHotelBooked("Ritz"))
+
+	booking.AddTransitions(
+		func(stateBookingStarted, inputBook) Fork { return Fork{completed} },
+		func(s stateBookingStarted, j Join) stateBooked { return j.Results },
+		func(stateBookingPending, inputBook) Fork { return Fork{} },
+		func(s stateBookingPending, j Join) stateBooked { return j.Results },
+	)
+
+	instance := booking.New(stateBookingStarted{})
+	require.NoError(t, instance.ContinueWith(inputBook{}))
+	require.Eventually(t, func() bool {
+		booked, isBooked := instance.CurrentState().(stateBooked)
+		return isBooked && len(booked) == 1
+	}, waitFor, tick)
+
+	empty := booking.New(stateBookingPending{})
+	require.NoError(t, empty.ContinueWith(inputBook{}))
+	require.Eventually(t, func() bool {
+		_, isBooked := empty.CurrentState().(stateBooked)
+		return isBooked
+	}, waitFor, tick)
+}
+
+func TestWorkflowInstance_ContinueWith_forkNilBranch(t *testing.T) {
+	t.Parallel()
+
+	booking, _, _ := newBookingWorkflows()
+	booking.AddTransition(func(stateBookingStarted, inputBook) Fork { return Fork{nil} })
+
+	require.PanicsWithValue(t, "forked branch must not be nil", func() {
+		_ = booking.New(stateBookingStarted{}).ContinueWith(inputBook{})
+	})
+}
+
+func TestWorkflowInstance_ContinueWith_forkJoinFailed(t *testing.T) {
+	t.Parallel()
+
+	booking, _, _ := newBookingWorkflows()
+	booking.AddTransition(func(stateBookingStarted, inputBook) Fork { return Fork{} })
+
+	type failure struct {
+		err           error
+		previousState any
+		input         []any
+	}
+
+	failed := make(chan failure, 1)
+	booking.AddTransitionFailedAction(func(err error, previousState any, input ...any) {
+		failed <- failure{err, previousState, input}
+	})
+
+	require.NoError(t, booking.New(stateBookingStarted{}).ContinueWith(inputBook{}))
+	require.Equal(t, failure{ErrTransitionDoesNotExist, stateBookingStarted{}, []any{Join{}}}, <-failed)
+}
+
+func TestWorkflowInstance_ContinueWith_forkBranchFailed(t *testing.T) {
+	t.Parallel()
+
+	booking, flight, _ := newBookingWorkflows()
+
+	errSoldOut := errors.New("sold out")
+	flight.RemoveTransition(stateFlightRequested{})
+	flight.AddTransition(func(stateFlightRequested) (stateFlightBooked, error) { return "", errSoldOut })
+
+	branch := flight.New(stateFlightRequested{})
+	booking.AddTransition(func(stateBookingStarted, inputBook) Fork { return Fork{branch} })
+
+	type failure struct {
+		err           error
+		previousState any
+		input         []any
+	}
+
+	failed := make(chan failure, 1)
+	booking.AddTransitionFailedAction(func(err error, previousState any, input ...any) {
+		failed <- failure{err, previousState, input}
+	})
+
+	require.NoError(t, booking.New(stateBookingStarted{}).ContinueWith(inputBook{}))
+
+	f := <-failed
+	require.ErrorIs(t, f.err, ErrBranchFailed)
+	require.ErrorIs(t, f.err, errSoldOut)
+	require.Equal(t, stateBookingStarted{}, f.previousState)
+	require.Equal(t, []any{branch}, f.input)
+}
@@ -0,0 +1,48 @@
+package ekstatic
+
+import (
+	"errors"
+)
+
+var ErrWorkflowFrozen = errors.New("workflow is frozen and can't be changed anymore")
+
+// Freeze makes the workflow immutable. Afterwards, instances look up
+// transitions without locking, and all methods changing the workflow panic
+// with ErrWorkflowFrozen, except Merge, which returns it. Use Clone to
+// derive a workflow that can be changed again.
+//
+// Transitions, timeouts, final states and failure edges can be added to a
+// workflow that isn't frozen while instances are running. Actions, policies,
+// the clock and the mailbox should be configured before.
+func (w *Workflow) Freeze() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.frozen.Store(true)
+}
+
+func (w *Workflow) IsFrozen() bool {
+	return w.frozen.Load()
+}
+
+// lock locks the workflow for changing it and panics with ErrWorkflowFrozen
+// if it is frozen.
+func (w *Workflow) lock() {
+	w.mu.Lock()
+
+	if w.frozen.Load() {
+		w.mu.Unlock()
+		panic(ErrWorkflowFrozen)
+	}
+}
+
+// rlock locks the workflow for reading unless it is frozen, and returns the
+// function to unlock it.
+func (w *Workflow) rlock() func() {
+	if w.frozen.Load() {
+		return func() {}
+	}
+
+	w.mu.RLock()
+	return w.mu.RUnlock
+}
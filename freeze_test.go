@@ -0,0 +1,95 @@
+package ekstatic
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflow_Freeze(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(s string, i int) string { return s + "a" })
+	require.False(t, w.IsFrozen())
+
+	w.Freeze()
+	require.True(t, w.IsFrozen())
+
+	testcases := []struct {
+		name   string
+		mutate func()
+	}{
+		{"AddTransition", func() { w.AddTransition(func(s string, b bool) string { return s }) }},
+		{"ReplaceTransition", func() { w.ReplaceTransition(func(s string, i int) string { return s }) }},
+		{"RemoveTransition", func() { w.RemoveTransition("", 0) }},
+		{"AddTransitionSucceededAction", func() { w.AddTransitionSucceededAction(nil) }},
+		{"AddTransitionFailedAction", func() { w.AddTransitionFailedAction(nil) }},
+		{"AddTimeout", func() { w.AddTimeout("", time.Second, func() any { return 0 }) }},
+		{"AddFinalStates", func() { w.AddFinalStates(0) }},
+		{"AddFailureEdges", func() { w.AddFailureEdges(OnError[error]("", 0)) }},
+		{"AddDuplicateInputAction", func() { w.AddDuplicateInputAction(nil) }},
+		{"SetClock", func() { w.SetClock(nil) }},
+		{"SetMailbox", func() { w.SetMailbox(1, OverflowReject) }},
+		{"SetTimerStore", func() { w.SetTimerStore(nil) }},
+		{"SetIdempotencyWindow", func() { w.SetIdempotencyWindow(1) }},
+		{"SetCompensationPolicy", func() { w.SetCompensationPolicy(CompensateOnFailure) }},
+		{"SetRetryPolicy", func() { w.SetRetryPolicy(RetryPolicy{}) }},
+		{"SetHistoryCapacity", func() { w.SetHistoryCapacity(1) }},
+		{"SetHistorySink", func() { w.SetHistorySink(nil) }},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			require.PanicsWithValue(t, ErrWorkflowFrozen, tt.mutate)
+		})
+	}
+
+	require.Equal(t, ErrWorkflowFrozen, w.Merge(NewWorkflow(), MergeOverride))
+
+	instance := w.New("")
+	require.NoError(t, instance.ContinueWith(1))
+	require.Equal(t, "a", instance.CurrentState())
+
+	clone := w.Clone()
+	require.False(t, clone.IsFrozen())
+	clone.AddTransition(func(s string, b bool) string { return s + "b" })
+	require.ErrorIs(t, instance.ContinueWith(true), ErrTransitionDoesNotExist)
+}
+
+func TestWorkflow_AddTransition_concurrent(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(s string, i int) string { return s })
+
+	instance := w.New("")
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			_ = instance.ContinueWith(1)
+		}()
+
+		go func(i int) {
+			defer wg.Done()
+			w.AddFailureEdges(OnError[error](i, fmt.Sprint(i)))
+			w.AddFinalStates(i)
+		}(i)
+	}
+
+	w.AddTransition(func(s string, b bool) string { return s + "b" })
+	wg.Wait()
+
+	require.NoError(t, instance.ContinueWith(true))
+	require.Equal(t, "b", instance.CurrentState())
+}
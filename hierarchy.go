@@ -0,0 +1,76 @@
+package ekstatic
+
+import (
+	"reflect"
+)
+
+// Substate is implemented by states that are nested in a parent state. If
+// there is no transition for a substate and an input, the transition for
+// its parent state and that input is used, walking up the hierarchy until
+// one is found. The transition then receives the value returned by
+// ParentState instead of the substate.
+//
+// ε-transitions aren't inherited.
+type Substate interface {
+	ParentState() any
+}
+
+// resolveTransition returns the identifier of the transition for the state
+// and input along with the state, or ancestor of the state, it has been
+// registered for.
+func (w *Workflow) resolveTransition(state any, input ...any) (transitionIdentifer, any, bool) {
+	defer w.rlock()()
+
+	var visited map[reflect.Type]struct{}
+
+	for state != nil {
+		identifier := identifierFromArguments(state, input...)
+		if _, exists := w.transitions[identifier]; exists {
+			return identifier, state, true
+		}
+
+		substate, isSubstate := state.(Substate)
+		if !isSubstate {
+			break
+		}
+
+		if visited == nil {
+			visited = make(map[reflect.Type]struct{})
+		}
+		visited[reflect.TypeOf(state)] = struct{}{}
+		state = substate.ParentState()
+
+		if _, isCycle := visited[reflect.TypeOf(state)]; isCycle {
+			break
+		}
+	}
+
+	return nil, nil, false
+}
+
+// ancestors returns the parent states of the state, innermost first.
+func ancestors(state any) []any {
+	var (
+		parents []any
+		visited = map[reflect.Type]struct{}{reflect.TypeOf(state): {}}
+	)
+
+	for {
+		substate, isSubstate := state.(Substate)
+		if !isSubstate {
+			return parents
+		}
+
+		state = substate.ParentState()
+		if state == nil {
+			return parents
+		}
+
+		if _, isCycle := visited[reflect.TypeOf(state)]; isCycle {
+			return parents
+		}
+		visited[reflect.TypeOf(state)] = struct{}{}
+
+		parents = append(parents, state)
+	}
+}
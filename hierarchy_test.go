@@ -0,0 +1,131 @@
+package ekstatic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateTicket struct {
+		id int
+	}
+	stateTicketOpen struct {
+		stateTicket
+	}
+	stateTicketNew struct {
+		stateTicketOpen
+	}
+	stateTicketInProgress struct {
+		stateTicketOpen
+		assignee string
+	}
+	stateTicketClosed struct {
+		stateTicket
+		reason string
+	}
+
+	stateCycleA struct{}
+	stateCycleB struct{}
+
+	inputAssign   string
+	inputClose    string
+	inputEscalate struct{}
+)
+
+func (s stateTicketOpen) ParentState() any       { return s.stateTicket }
+func (s stateTicketNew) ParentState() any        { return s.stateTicketOpen }
+func (s stateTicketInProgress) ParentState() any { return s.stateTicketOpen }
+func (s stateTicketClosed) ParentState() any     { return s.stateTicket }
+func (stateCycleA) ParentState() any             { return stateCycleB{} }
+func (stateCycleB) ParentState() any             { return stateCycleA{} }
+
+func newTicketWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(s stateTicketOpen, i inputAssign) stateTicketInProgress {
+			return stateTicketInProgress{s, string(i)}
+		},
+		func(s stateTicketOpen, i inputClose) stateTicketClosed {
+			return stateTicketClosed{s.stateTicket, "open: " + string(i)}
+		},
+		func(s stateTicketInProgress, i inputClose) stateTicketClosed {
+			return stateTicketClosed{s.stateTicket, s.assignee + ": " + string(i)}
+		},
+		func(s stateTicket, i inputEscalate) stateTicketNew {
+			return stateTicketNew{stateTicketOpen{s}}
+		},
+	)
+
+	return w
+}
+
+func TestWorkflowInstance_ContinueWith_hierarchy(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		initialState     any
+		inputs           []any
+		destinationState any
+		err              error
+	}{
+		{
+			name:             "transition inherited from parent",
+			initialState:     stateTicketNew{stateTicketOpen{stateTicket{1}}},
+			inputs:           []any{inputAssign("alex")},
+			destinationState: stateTicketInProgress{stateTicketOpen{stateTicket{1}}, "alex"},
+		},
+		{
+			name:             "transition inherited from grandparent",
+			initialState:     stateTicketClosed{stateTicket{1}, "done"},
+			inputs:           []any{inputEscalate{}},
+			destinationState: stateTicketNew{stateTicketOpen{stateTicket{1}}},
+		},
+		{
+			name:             "transition overridden by child",
+			initialState:     stateTicketNew{stateTicketOpen{stateTicket{1}}},
+			inputs:           []any{inputAssign("alex"), inputClose("fixed")},
+			destinationState: stateTicketClosed{stateTicket{1}, "alex: fixed"},
+		},
+		{
+			name:             "transition of parent",
+			initialState:     stateTicketNew{stateTicketOpen{stateTicket{1}}},
+			inputs:           []any{inputClose("duplicate")},
+			destinationState: stateTicketClosed{stateTicket{1}, "open: duplicate"},
+		},
+		{
+			name:             "no transition in hierarchy",
+			initialState:     stateTicketClosed{stateTicket{1}, "done"},
+			inputs:           []any{inputAssign("alex")},
+			destinationState: stateTicketClosed{stateTicket{1}, "done"},
+			err:              ErrTransitionDoesNotExist,
+		},
+		{
+			name:             "cyclic hierarchy",
+			initialState:     stateCycleA{},
+			inputs:           []any{inputAssign("alex")},
+			destinationState: stateCycleA{},
+			err:              ErrTransitionDoesNotExist,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			instance := newTicketWorkflow().New(tt.initialState)
+
+			var err error
+			for _, input := range tt.inputs {
+				if err = instance.ContinueWith(input); err != nil {
+					break
+				}
+			}
+
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package ekstatic
+
+import (
+	"reflect"
+)
+
+type (
+	// HistoryState can be returned by a transition to return to the substate
+	// of parent that was active when the instance last left it. With shallow
+	// history, that is the direct child of parent the last active state
+	// descended from, with deep history the last active state itself. If the
+	// instance has never left parent, it enters the fallback state.
+	HistoryState struct {
+		parent   reflect.Type
+		deep     bool
+		fallback any
+	}
+
+	// SubstateHistory records the last active substates of a parent state.
+	SubstateHistory struct {
+		Shallow any
+		Deep    any
+	}
+)
+
+func ShallowHistory(parent, fallback any) HistoryState {
+	return newHistoryState(parent, fallback, false)
+}
+
+func DeepHistory(parent, fallback any) HistoryState {
+	return newHistoryState(parent, fallback, true)
+}
+
+func newHistoryState(parent, fallback any, deep bool) HistoryState {
+	if parent == nil {
+		panic("history parent state must not be nil")
+	}
+
+	if fallback == nil {
+		panic("history fallback state must not be nil")
+	}
+
+	return HistoryState{reflect.TypeOf(parent), deep, fallback}
+}
+
+// recordHistory records the substates of all parent states the instance
+// left when leaving previousState.
+func (w *WorkflowInstance) recordHistory(previousState any) {
+	left := ancestors(previousState)
+	if len(left) == 0 {
+		return
+	}
+
+	remaining := make(map[reflect.Type]struct{})
+	remaining[reflect.TypeOf(w.currentState)] = struct{}{}
+	for _, parent := range ancestors(w.currentState) {
+		remaining[reflect.TypeOf(parent)] = struct{}{}
+	}
+
+	if w.history == nil {
+		w.history = make(map[string]SubstateHistory)
+	}
+
+	child := previousState
+	for _, parent := range left {
+		if _, isRemaining := remaining[reflect.TypeOf(parent)]; !isRemaining {
+			w.history[reflect.TypeOf(parent).String()] = SubstateHistory{
+				Shallow: child,
+				Deep:    previousState,
+			}
+		}
+		child = parent
+	}
+}
+
+func (w *WorkflowInstance) restoreHistory(h HistoryState) any {
+	recorded, exists := w.history[h.parent.String()]
+	switch {
+	case !exists:
+		return h.fallback
+	case h.deep:
+		return recorded.Deep
+	default:
+		return recorded.Shallow
+	}
+}
@@ -0,0 +1,132 @@
+package ekstatic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateCallActive  struct{}
+	stateCallDialing struct{}
+	stateCallTalking struct {
+		volume int
+	}
+	stateCallOnHold struct {
+		stateCallTalking
+	}
+	stateCallPaused struct{}
+
+	inputConnect struct{}
+	inputHold    struct{}
+	inputPause   struct{}
+	inputResume  bool
+)
+
+func (stateCallDialing) ParentState() any  { return stateCallActive{} }
+func (stateCallTalking) ParentState() any  { return stateCallActive{} }
+func (s stateCallOnHold) ParentState() any { return s.stateCallTalking }
+
+func newCallWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(stateCallDialing, inputConnect) stateCallTalking { return stateCallTalking{7} },
+		func(s stateCallTalking, i inputHold) stateCallOnHold { return stateCallOnHold{s} },
+		func(stateCallActive, inputPause) stateCallPaused { return stateCallPaused{} },
+		func(s stateCallPaused, deep inputResume) HistoryState {
+			if deep {
+				return DeepHistory(stateCallActive{}, stateCallDialing{})
+			}
+			return ShallowHistory(stateCallActive{}, stateCallDialing{})
+		},
+	)
+
+	return w
+}
+
+func TestHistoryState(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithValue(t, "history parent state must not be nil", func() {
+		ShallowHistory(nil, stateCallDialing{})
+	})
+	require.PanicsWithValue(t, "history fallback state must not be nil", func() {
+		DeepHistory(stateCallActive{}, nil)
+	})
+}
+
+func TestWorkflowInstance_ContinueWith_history(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		initialState     any
+		inputs           []any
+		destinationState any
+	}{
+		{
+			name:             "fallback without history",
+			initialState:     stateCallPaused{},
+			inputs:           []any{inputResume(false)},
+			destinationState: stateCallDialing{},
+		},
+		{
+			name:             "shallow history",
+			initialState:     stateCallDialing{},
+			inputs:           []any{inputConnect{}, inputHold{}, inputPause{}, inputResume(false)},
+			destinationState: stateCallTalking{7},
+		},
+		{
+			name:             "deep history",
+			initialState:     stateCallDialing{},
+			inputs:           []any{inputConnect{}, inputHold{}, inputPause{}, inputResume(true)},
+			destinationState: stateCallOnHold{stateCallTalking{7}},
+		},
+		{
+			name:             "history is updated each time the parent is left",
+			initialState:     stateCallDialing{},
+			inputs:           []any{inputPause{}, inputResume(true), inputConnect{}, inputPause{}, inputResume(true)},
+			destinationState: stateCallTalking{7},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			instance := newCallWorkflow().New(tt.initialState)
+			for _, input := range tt.inputs {
+				require.NoError(t, instance.ContinueWith(input))
+			}
+
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+		})
+	}
+}
+
+func TestWorkflowInstance_ContinueWith_historyRestored(t *testing.T) {
+	t.Parallel()
+
+	w := newCallWorkflow()
+	instance := w.New(stateCallDialing{})
+	require.NoError(t, instance.ContinueWith(inputConnect{}))
+	require.NoError(t, instance.ContinueWith(inputHold{}))
+	require.NoError(t, instance.ContinueWith(inputPause{}))
+
+	snapshot := instance.Snapshot()
+	require.Equal(t, map[string]SubstateHistory{
+		"ekstatic.stateCallActive": {
+			Shallow: stateCallTalking{7},
+			Deep:    stateCallOnHold{stateCallTalking{7}},
+		},
+		"ekstatic.stateCallTalking": {
+			Shallow: stateCallOnHold{stateCallTalking{7}},
+			Deep:    stateCallOnHold{stateCallTalking{7}},
+		},
+	}, snapshot.SubstateHistory)
+
+	restored := w.Restore("restored", snapshot)
+	require.NoError(t, restored.ContinueWith(inputResume(true)))
+	require.Equal(t, stateCallOnHold{stateCallTalking{7}}, restored.CurrentState())
+}
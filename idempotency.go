@@ -0,0 +1,117 @@
+package ekstatic
+
+import (
+	"strings"
+)
+
+const defaultIdempotencyWindow = 1024
+
+type (
+	DuplicateInputAction func(key string, currentState any, input ...any)
+
+	// ProcessedInput records a KeyedInput that has been applied.
+	ProcessedInput struct {
+		Key string
+	}
+
+	processedInputs struct {
+		keys    []string
+		applied map[string]struct{}
+	}
+)
+
+// SetIdempotencyWindow sets how many keys of applied inputs an instance
+// remembers. Applying an input whose key is remembered succeeds without
+// performing a transition. Keys of inputs whose own transition failed
+// aren't remembered, so they can be delivered again. Failures of the
+// ε-transitions and emitted inputs that follow don't count. A window of
+// less than zero disables deduplication.
+func (w *Workflow) SetIdempotencyWindow(size int) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.idempotencyWindow = size
+}
+
+func (w *Workflow) AddDuplicateInputAction(onDuplicateInput DuplicateInputAction) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.onDuplicateInput = onDuplicateInput
+}
+
+func (w *Workflow) getIdempotencyWindow() int {
+	if w.idempotencyWindow == 0 {
+		return defaultIdempotencyWindow
+	}
+
+	return w.idempotencyWindow
+}
+
+// continueWithKey applies the input unless it carries a key of an input that
+// has been applied before.
+func (w *WorkflowInstance) continueWithKey(input ...any) error {
+	key, isKeyed := inputKey(input)
+	window := w.workflow.getIdempotencyWindow()
+
+	if !isKeyed || window < 0 {
+		return w.continueWithEmitted(input...)
+	}
+
+	if _, applied := w.processed.applied[key]; applied {
+		if w.workflow.onDuplicateInput != nil {
+			w.workflow.onDuplicateInput(key, w.currentState, input...)
+		}
+		return nil
+	}
+
+	// The key is remembered once the transition of the input is committed,
+	// even if ε-transitions or emitted inputs fail afterwards.
+	w.onCommit = func() { w.processed.add(key, window) }
+	defer func() { w.onCommit = nil }()
+
+	err := w.continueWithEmitted(input...)
+	if err == nil {
+		w.commit()
+	}
+
+	return err
+}
+
+func (p *processedInputs) add(key string, window int) {
+	if p.applied == nil {
+		p.applied = make(map[string]struct{})
+	}
+
+	p.keys = append(p.keys, key)
+	p.applied[key] = struct{}{}
+
+	for len(p.keys) > window {
+		delete(p.applied, p.keys[0])
+		p.keys = p.keys[1:]
+	}
+}
+
+func (p *processedInputs) list() []ProcessedInput {
+	if len(p.keys) == 0 {
+		return nil
+	}
+
+	list := make([]ProcessedInput, len(p.keys))
+	for i, key := range p.keys {
+		list[i] = ProcessedInput{key}
+	}
+
+	return list
+}
+
+func inputKey(input []any) (string, bool) {
+	var keys []string
+	for _, i := range input {
+		if keyed, ok := i.(KeyedInput); ok {
+			keys = append(keys, keyed.IdempotencyKey())
+		}
+	}
+
+	return strings.Join(keys, "/"), len(keys) > 0
+}
@@ -0,0 +1,184 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newIdempotentWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(state string, input keyedInput) (string, error) {
+			if input.value == "" {
+				return "", errors.New("empty value")
+			}
+			return state + input.value, nil
+		},
+		func(state string, input string) string { return state + input },
+	)
+
+	return w
+}
+
+func TestWorkflowInstance_ContinueWith_idempotent(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		window           int
+		inputs           []any
+		destinationState any
+		errs             []error
+	}{
+		{
+			name:             "duplicate keyed input is skipped",
+			inputs:           []any{keyedInput{"1", "a"}, keyedInput{"2", "b"}, keyedInput{"1", "a"}},
+			destinationState: "ab",
+			errs:             []error{nil, nil, nil},
+		},
+		{
+			name:             "unkeyed input is never skipped",
+			inputs:           []any{"a", "a"},
+			destinationState: "aa",
+			errs:             []error{nil, nil},
+		},
+		{
+			name:             "failed input is applied when delivered again",
+			inputs:           []any{keyedInput{"1", ""}, keyedInput{"1", "a"}, keyedInput{"1", "a"}},
+			destinationState: "a",
+			errs:             []error{errors.New("empty value"), nil, nil},
+		},
+		{
+			name:             "keys are forgotten outside of window",
+			window:           1,
+			inputs:           []any{keyedInput{"1", "a"}, keyedInput{"2", "b"}, keyedInput{"2", "b"}, keyedInput{"1", "a"}},
+			destinationState: "aba",
+			errs:             []error{nil, nil, nil, nil},
+		},
+		{
+			name:             "deduplication disabled",
+			window:           -1,
+			inputs:           []any{keyedInput{"1", "a"}, keyedInput{"1", "a"}},
+			destinationState: "aa",
+			errs:             []error{nil, nil},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w := newIdempotentWorkflow()
+			w.SetIdempotencyWindow(tt.window)
+
+			instance := w.New("")
+			for i, input := range tt.inputs {
+				require.Equal(t, tt.errs[i], instance.ContinueWith(input))
+			}
+
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+		})
+	}
+}
+
+func TestWorkflowInstance_ContinueWith_idempotentFollowUpFailed(t *testing.T) {
+	t.Parallel()
+
+	type (
+		stateStarted  struct{}
+		stateApplied  struct{}
+		stateReplayed struct{}
+	)
+
+	errFollowUp := errors.New("follow-up failed")
+
+	testcases := []struct {
+		name        string
+		transitions []Transition
+	}{
+		{
+			name: "ε-transition",
+			transitions: []Transition{
+				func(s stateStarted, i keyedInput) stateApplied { return stateApplied{} },
+				func(s stateApplied) (stateStarted, error) { return stateStarted{}, errFollowUp },
+			},
+		},
+		{
+			name: "emitted input",
+			transitions: []Transition{
+				func(s stateStarted, i keyedInput, e Emitter) stateApplied {
+					e.Emit("x")
+					return stateApplied{}
+				},
+				func(s stateApplied, i string) (stateStarted, error) { return stateStarted{}, errFollowUp },
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w := NewWorkflow()
+			w.AddTransitions(tt.transitions...)
+			w.AddTransition(func(s stateApplied, i keyedInput) stateReplayed { return stateReplayed{} })
+
+			instance := w.New(stateStarted{})
+			require.ErrorIs(t, instance.ContinueWith(keyedInput{"1", "a"}), errFollowUp)
+			require.NoError(t, instance.ContinueWith(keyedInput{"1", "a"}))
+			require.Equal(t, stateApplied{}, instance.CurrentState())
+			require.Equal(t, []ProcessedInput{{"1"}}, instance.Snapshot().ProcessedInputs)
+		})
+	}
+}
+
+func TestWorkflow_AddDuplicateInputAction(t *testing.T) {
+	t.Parallel()
+
+	w := newIdempotentWorkflow()
+
+	var duplicates []string
+	w.AddDuplicateInputAction(func(key string, currentState any, input ...any) {
+		require.Equal(t, "a", currentState)
+		require.Equal(t, []any{keyedInput{"1", "a"}}, input)
+		duplicates = append(duplicates, key)
+	})
+
+	succeeded := 0
+	w.AddTransitionSucceededAction(func(newState, previousState any, input ...any) { succeeded++ })
+
+	instance := w.New("")
+	require.NoError(t, instance.ContinueWith(keyedInput{"1", "a"}))
+	require.NoError(t, instance.ContinueWith(keyedInput{"1", "a"}))
+	require.NoError(t, instance.ContinueWith(keyedInput{"1", "a"}))
+
+	require.Equal(t, []string{"1", "1"}, duplicates)
+	require.Equal(t, 1, succeeded)
+}
+
+func TestWorkflowInstance_ContinueWith_idempotentRestored(t *testing.T) {
+	t.Parallel()
+
+	w := newIdempotentWorkflow()
+	w.SetIdempotencyWindow(2)
+
+	instance := w.New("")
+	require.NoError(t, instance.ContinueWith(keyedInput{"1", "a"}))
+	require.NoError(t, instance.ContinueWith(keyedInput{"2", "b"}))
+	require.Error(t, instance.ContinueWith(keyedInput{"3", ""}))
+	require.NoError(t, instance.ContinueWith(keyedInput{"4", "d"}))
+
+	snapshot := instance.Snapshot()
+	require.Equal(t, []ProcessedInput{{"2"}, {"4"}}, snapshot.ProcessedInputs)
+
+	restored := w.Restore("restored", snapshot)
+	require.NoError(t, restored.ContinueWith(keyedInput{"4", "d"}))
+	require.NoError(t, restored.ContinueWith(keyedInput{"2", "b"}))
+	require.NoError(t, restored.ContinueWith(keyedInput{"3", "c"}))
+	require.NoError(t, restored.ContinueWith(keyedInput{"1", "a"}))
+	require.Equal(t, "abdca", restored.CurrentState())
+}
@@ -0,0 +1,156 @@
+package ekstatic
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// TransitionDescriptor describes a transition of a workflow.
+type TransitionDescriptor struct {
+	State  reflect.Type
+	Inputs []reflect.Type
+
+	// Result is the declared result type of the transition, and Results
+	// holds the types of the states it can return. Results is empty if
+	// Result is an interface type and no Targets have been declared.
+	Result  reflect.Type
+	Results []reflect.Type
+
+	CanFail bool
+	Epsilon bool
+
+	// Name is the name of the function implementing the transition as
+	// reported by runtime.FuncForPC.
+	Name string
+}
+
+// Transitions returns descriptors of all transitions of the workflow, sorted
+// by the types of their states and inputs.
+func (w *Workflow) Transitions() []TransitionDescriptor {
+	unlock := w.rlock()
+
+	identifiers := make([]string, 0, len(w.transitions))
+	transitions := make(map[string]Transition, len(w.transitions))
+	configs := make(map[string]transitionConfig, len(w.transitionConfigs))
+	for identifier, t := range w.transitions {
+		identifiers = append(identifiers, identifier.(string))
+		transitions[identifier.(string)] = t
+		configs[identifier.(string)] = w.transitionConfigs[identifier]
+	}
+
+	unlock()
+
+	slices.Sort(identifiers)
+
+	descriptors := make([]TransitionDescriptor, len(identifiers))
+	for i, identifier := range identifiers {
+		descriptors[i] = describeTransition(transitions[identifier], configs[identifier])
+	}
+
+	return descriptors
+}
+
+func describeTransition(t Transition, config transitionConfig) TransitionDescriptor {
+	transitionType := reflect.TypeOf(t)
+
+	numIn := transitionType.NumIn()
+	if acceptsEmitter(transitionType) {
+		numIn--
+	}
+
+	descriptor := TransitionDescriptor{
+		State:   transitionType.In(0),
+		Result:  transitionType.Out(0),
+		CanFail: transitionType.NumOut() == 2,
+		Epsilon: numIn == 1,
+	}
+
+	for i := 1; i < numIn; i++ {
+		descriptor.Inputs = append(descriptor.Inputs, transitionType.In(i))
+	}
+
+	switch {
+	case config.targets != nil:
+		descriptor.Results = slices.Clone(config.targets)
+	case descriptor.Result.Kind() != reflect.Interface:
+		descriptor.Results = []reflect.Type{descriptor.Result}
+	}
+
+	if f := runtime.FuncForPC(reflect.ValueOf(t).Pointer()); f != nil {
+		descriptor.Name = f.Name()
+	}
+
+	return descriptor
+}
+
+// AvailableInputs returns the input types of all transitions that can be
+// performed from the given state, including those inherited from its parent
+// states. ε-transitions and inputs that would be forwarded to a submachine
+// aren't included.
+func (w *Workflow) AvailableInputs(state any) [][]reflect.Type {
+	if state == nil {
+		panic("state must not be nil")
+	}
+
+	var (
+		inputs      [][]reflect.Type
+		seen        = make(map[string]struct{})
+		descriptors = w.Transitions()
+	)
+
+	for _, s := range append([]any{state}, ancestors(state)...) {
+		for _, descriptor := range descriptors {
+			if descriptor.State != reflect.TypeOf(s) || descriptor.Epsilon {
+				continue
+			}
+
+			key := typesString(descriptor.Inputs)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			inputs = append(inputs, descriptor.Inputs)
+		}
+	}
+
+	return inputs
+}
+
+// CanContinueWith reports whether ContinueWith would find a transition for
+// the input, either in the workflow of the instance or in the submachine of
+// its current state.
+func (w *WorkflowInstance) CanContinueWith(input ...any) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.accepts(input...)
+}
+
+func (w *WorkflowInstance) accepts(input ...any) bool {
+	if _, _, exists := w.workflow.resolveTransition(w.currentState, input...); exists {
+		return true
+	}
+
+	sub, hasSubmachine := w.currentState.(submachine)
+	return hasSubmachine && sub.canForward(input...)
+}
+
+func (w *WorkflowInstance) canForward(input ...any) bool {
+	if w == nil {
+		return false
+	}
+
+	return w.CanContinueWith(input...)
+}
+
+func typesString(types []reflect.Type) string {
+	var b strings.Builder
+	for _, t := range types {
+		b.WriteString(t.String())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package ekstatic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func namedTransition(s stateCallActive, i inputPause) (stateCallPaused, error) {
+	return stateCallPaused{}, nil
+}
+
+func TestWorkflow_Transitions(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransitions(
+		namedTransition,
+		func(s string, i int, b bool, e Emitter) any { return s },
+		func(s string) int { return 0 },
+	)
+
+	descriptors := w.Transitions()
+	require.Len(t, descriptors, 3)
+
+	require.Equal(t, reflect.TypeFor[stateCallActive](), descriptors[0].State)
+	require.Equal(t, []reflect.Type{reflect.TypeFor[inputPause]()}, descriptors[0].Inputs)
+	require.Equal(t, reflect.TypeFor[stateCallPaused](), descriptors[0].Result)
+	require.Equal(t, []reflect.Type{reflect.TypeFor[stateCallPaused]()}, descriptors[0].Results)
+	require.True(t, descriptors[0].CanFail)
+	require.False(t, descriptors[0].Epsilon)
+	require.Equal(t, "github.com/metamogul/ekstatic.namedTransition", descriptors[0].Name)
+
+	require.Equal(t, reflect.TypeFor[string](), descriptors[1].State)
+	require.Nil(t, descriptors[1].Inputs)
+	require.Equal(t, []reflect.Type{reflect.TypeFor[int]()}, descriptors[1].Results)
+	require.False(t, descriptors[1].CanFail)
+	require.True(t, descriptors[1].Epsilon)
+
+	require.Equal(t, []reflect.Type{reflect.TypeFor[int](), reflect.TypeFor[bool]()}, descriptors[2].Inputs)
+	require.Equal(t, reflect.TypeFor[any](), descriptors[2].Result)
+	require.Empty(t, descriptors[2].Results)
+	require.False(t, descriptors[2].Epsilon)
+	require.Contains(t, descriptors[2].Name, "TestWorkflow_Transitions")
+}
+
+func TestWorkflow_AvailableInputs(t *testing.T) {
+	t.Parallel()
+
+	w := newCallWorkflow()
+	w.AddTransition(func(stateCallOnHold, inputPause) stateCallPaused { return stateCallPaused{} })
+	w.AddTransition(func(stateCallTalking) stateCallTalking { return stateCallTalking{} })
+
+	testcases := []struct {
+		name   string
+		state  any
+		inputs [][]reflect.Type
+	}{
+		{
+			name:   "no transitions",
+			state:  "foo",
+			inputs: nil,
+		},
+		{
+			name:  "own and inherited transitions",
+			state: stateCallTalking{},
+			inputs: [][]reflect.Type{
+				{reflect.TypeFor[inputHold]()},
+				{reflect.TypeFor[inputPause]()},
+			},
+		},
+		{
+			name:  "overridden transition",
+			state: stateCallOnHold{},
+			inputs: [][]reflect.Type{
+				{reflect.TypeFor[inputPause]()},
+				{reflect.TypeFor[inputHold]()},
+			},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			require.Equal(t, tt.inputs, w.AvailableInputs(tt.state))
+		})
+	}
+
+	require.PanicsWithValue(t, "state must not be nil", func() { w.AvailableInputs(nil) })
+}
+
+func TestWorkflowInstance_CanContinueWith(t *testing.T) {
+	t.Parallel()
+
+	call := newCallWorkflow().New(stateCallDialing{})
+	require.True(t, call.CanContinueWith(inputConnect{}))
+	require.True(t, call.CanContinueWith(inputPause{}))
+	require.False(t, call.CanContinueWith(inputHold{}))
+	require.Equal(t, stateCallDialing{}, call.CurrentState())
+
+	orderWorkflow := NewWorkflow()
+	orderWorkflow.AddTransition(func(s stateOrderOpen, c inputCancel) stateOrderDone { return nil })
+
+	payment, shipping := newOrderRegions()
+	order := orderWorkflow.New(stateOrderOpen{NewParallel(payment, shipping)})
+	require.True(t, order.CanContinueWith(inputCancel{}))
+	require.True(t, order.CanContinueWith(inputShip("")))
+	require.False(t, order.CanContinueWith(inputConnect{}))
+	require.False(t, orderWorkflow.New(stateOrderOpen{}).CanContinueWith(inputShip("")))
+}
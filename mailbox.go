@@ -0,0 +1,182 @@
+package ekstatic
+
+import (
+	"errors"
+	"sync"
+)
+
+const defaultMailboxCapacity = 64
+
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Send wait until there is room in the mailbox.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowReject makes Send fail with ErrMailboxFull.
+	OverflowReject
+	// OverflowDropOldest discards the oldest queued input to make room.
+	OverflowDropOldest
+)
+
+var ErrMailboxFull = errors.New("mailbox of workflow instance is full")
+var ErrInputDropped = errors.New("input was dropped from the mailbox of workflow instance")
+var ErrInstanceBusy = errors.New("workflow instance still has inputs queued in its mailbox")
+
+type (
+	// Future holds the outcome of an input sent to the mailbox of a
+	// WorkflowInstance.
+	Future struct {
+		input []any
+		state any
+		err   error
+		done  chan struct{}
+	}
+
+	mailbox struct {
+		queue   []*Future
+		running bool
+
+		mu      sync.Mutex
+		notFull *sync.Cond
+	}
+)
+
+// SetMailbox configures the capacity of the mailboxes of all instances of
+// the workflow and what happens if Send is called on a full mailbox.
+func (w *Workflow) SetMailbox(capacity int, overflow OverflowPolicy) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.mailboxCapacity = capacity
+	w.mailboxOverflow = overflow
+}
+
+// Send enqueues the input into the mailbox of the instance. Inputs are
+// applied asynchronously in the order they were sent, by a goroutine that
+// only runs while the mailbox isn't empty.
+func (w *WorkflowInstance) Send(input ...any) error {
+	_, err := w.send(input)
+	return err
+}
+
+// SendAndWait enqueues the input like Send and returns a Future to wait
+// for the resulting state.
+func (w *WorkflowInstance) SendAndWait(input ...any) *Future {
+	future, err := w.send(input)
+	if err != nil {
+		future.resolve(nil, err)
+	}
+
+	return future
+}
+
+func (w *WorkflowInstance) send(input []any) (*Future, error) {
+	future := &Future{
+		input: input,
+		done:  make(chan struct{}),
+	}
+
+	mb := w.getMailbox()
+	capacity, overflow := w.workflow.mailboxCapacity, w.workflow.mailboxOverflow
+	if capacity <= 0 {
+		capacity = defaultMailboxCapacity
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	for len(mb.queue) >= capacity {
+		switch overflow {
+		case OverflowReject:
+			return future, ErrMailboxFull
+		case OverflowDropOldest:
+			dropped := mb.queue[0]
+			mb.queue = mb.queue[1:]
+			dropped.resolve(nil, ErrInputDropped)
+		default:
+			mb.notFull.Wait()
+		}
+	}
+
+	mb.queue = append(mb.queue, future)
+
+	if !mb.running {
+		mb.running = true
+		go w.processMailbox(mb)
+	}
+
+	return future, nil
+}
+
+func (w *WorkflowInstance) processMailbox(mb *mailbox) {
+	for {
+		mb.mu.Lock()
+		if len(mb.queue) == 0 {
+			mb.running = false
+			mb.mu.Unlock()
+			return
+		}
+
+		future := mb.queue[0]
+		mb.queue = mb.queue[1:]
+		mb.notFull.Signal()
+		mb.mu.Unlock()
+
+		future.resolve(w.process(future.input))
+	}
+}
+
+func (w *WorkflowInstance) process(input []any) (any, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.evicted {
+		return w.currentState, ErrInstanceEvicted
+	}
+
+	err := w.withHistoryErrors(w.continueWithKey(input...))
+
+	return w.currentState, err
+}
+
+func (w *WorkflowInstance) getMailbox() *mailbox {
+	if mb := w.mailbox.Load(); mb != nil {
+		return mb
+	}
+
+	mb := &mailbox{}
+	mb.notFull = sync.NewCond(&mb.mu)
+	w.mailbox.CompareAndSwap(nil, mb)
+
+	return w.mailbox.Load()
+}
+
+func (w *WorkflowInstance) hasQueuedInput() bool {
+	mb := w.mailbox.Load()
+	if mb == nil {
+		return false
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	return mb.running
+}
+
+func (f *Future) resolve(state any, err error) {
+	f.state = state
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed once the input has been processed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the input has been processed and returns the resulting
+// state of the instance and the error of the transition, if any.
+func (f *Future) Wait() (any, error) {
+	<-f.done
+	return f.state, f.err
+}
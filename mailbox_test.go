@@ -0,0 +1,194 @@
+package ekstatic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	waitFor = time.Second
+	tick    = time.Millisecond
+)
+
+// newBlockingWorkflow returns a workflow whose transitions wait for a value
+// on the returned channel before they complete.
+func newBlockingWorkflow() (*Workflow, chan struct{}) {
+	release := make(chan struct{})
+
+	w := NewWorkflow()
+	w.AddTransition(func(state string, input string) string {
+		<-release
+		return state + input
+	})
+	w.AddTransition(func(state string, input int) (string, error) {
+		<-release
+		return "", errors.New("failed")
+	})
+
+	return w, release
+}
+
+func TestWorkflowInstance_Send(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(state string, input string) string { return state + input })
+
+	instance := w.New("")
+	for _, input := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, instance.Send(input))
+	}
+
+	state, err := instance.SendAndWait("e").Wait()
+	require.NoError(t, err)
+	require.Equal(t, "abcde", state)
+	require.Equal(t, "abcde", instance.CurrentState())
+}
+
+func TestWorkflowInstance_SendAndWait(t *testing.T) {
+	t.Parallel()
+
+	w, release := newBlockingWorkflow()
+	instance := w.New("Hello")
+
+	succeeding := instance.SendAndWait(", World!")
+	failing := instance.SendAndWait(1)
+	unknown := instance.SendAndWait(true)
+
+	select {
+	case <-succeeding.Done():
+		t.Fatal("future resolved before input was processed")
+	default:
+	}
+
+	release <- struct{}{}
+	state, err := succeeding.Wait()
+	require.NoError(t, err)
+	require.Equal(t, "Hello, World!", state)
+
+	release <- struct{}{}
+	state, err = failing.Wait()
+	require.EqualError(t, err, "failed")
+	require.Equal(t, "Hello, World!", state)
+
+	state, err = unknown.Wait()
+	require.ErrorIs(t, err, ErrTransitionDoesNotExist)
+	require.Equal(t, "Hello, World!", state)
+}
+
+func TestWorkflowInstance_Send_overflow(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name          string
+		overflow      OverflowPolicy
+		wantsSendErr  error
+		wantsFirstErr error
+		wantsState    string
+	}{
+		{
+			name:       "block",
+			overflow:   OverflowBlock,
+			wantsState: "abcd",
+		},
+		{
+			name:         "reject",
+			overflow:     OverflowReject,
+			wantsSendErr: ErrMailboxFull,
+			wantsState:   "abc",
+		},
+		{
+			name:          "drop oldest",
+			overflow:      OverflowDropOldest,
+			wantsFirstErr: ErrInputDropped,
+			wantsState:    "acd",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w, release := newBlockingWorkflow()
+			w.SetMailbox(2, tt.overflow)
+			instance := w.New("")
+
+			// Occupy the mailbox goroutine with the first input, so that the
+			// following two fill up the mailbox.
+			require.NoError(t, instance.Send("a"))
+			require.Eventually(t, func() bool { return queuedInputs(instance) == 0 }, waitFor, tick)
+
+			first := instance.SendAndWait("b")
+			second := instance.SendAndWait("c")
+
+			sent := make(chan error, 1)
+			go func() { sent <- instance.Send("d") }()
+
+			if tt.overflow == OverflowBlock {
+				require.Never(t, func() bool { return len(sent) > 0 }, 50*time.Millisecond, tick)
+				close(release)
+				require.NoError(t, <-sent)
+			} else {
+				require.ErrorIs(t, <-sent, tt.wantsSendErr)
+				close(release)
+			}
+
+			_, err := first.Wait()
+			require.ErrorIs(t, err, tt.wantsFirstErr)
+			_, err = second.Wait()
+			require.NoError(t, err)
+
+			require.Eventually(t, func() bool {
+				return instance.CurrentState() == tt.wantsState && !instance.hasQueuedInput()
+			}, waitFor, tick)
+		})
+	}
+}
+
+func TestWorkflowInstance_Send_evicted(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(newCountingWorkflow(), NewMemoryStore())
+	instance, err := m.New("a", 0)
+	require.NoError(t, err)
+	require.NoError(t, m.Evict("a"))
+
+	_, err = instance.SendAndWait(strings.Repeat("x", 3)).Wait()
+	require.ErrorIs(t, err, ErrInstanceEvicted)
+}
+
+func TestManager_Evict_busy(t *testing.T) {
+	t.Parallel()
+
+	w, release := newBlockingWorkflow()
+	m := NewManager(w, NewMemoryStore())
+	instance, err := m.New("a", "")
+	require.NoError(t, err)
+
+	future := instance.SendAndWait("b")
+	require.ErrorIs(t, m.Evict("a"), ErrInstanceBusy)
+
+	evicted, err := m.EvictIdle(0)
+	require.NoError(t, err)
+	require.Zero(t, evicted)
+
+	release <- struct{}{}
+	_, err = future.Wait()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return m.Evict("a") == nil }, waitFor, tick)
+}
+
+func queuedInputs(instance *WorkflowInstance) int {
+	mb := instance.getMailbox()
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	return len(mb.queue)
+}
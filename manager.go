@@ -0,0 +1,275 @@
+package ekstatic
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const managerShardCount = 64
+
+var ErrInstanceAlreadyExists = errors.New("there already is a workflow instance with that id")
+var ErrInstanceNotFound = errors.New("there is no workflow instance with that id")
+var ErrManagerHasNoStore = errors.New("manager has no store to evict instances to")
+
+type (
+	// Manager keeps track of many instances of the same workflow, addressing
+	// them by id. Instances are distributed over shards with separate locks,
+	// so that operations on unrelated instances don't contend. Idle instances
+	// can be evicted to a Store and are reloaded on their next use.
+	Manager struct {
+		workflow *Workflow
+		store    Store
+		shards   [managerShardCount]managerShard
+	}
+
+	managerShard struct {
+		instances map[string]*managedInstance
+
+		mu sync.RWMutex
+	}
+
+	managedInstance struct {
+		*WorkflowInstance
+		lastUsed atomic.Int64
+	}
+)
+
+// NewManager creates a Manager for instances of the given workflow. If store
+// is nil, instances are kept in memory only and can't be evicted.
+func NewManager(workflow *Workflow, store Store) *Manager {
+	if workflow == nil {
+		panic("workflow must not be nil")
+	}
+
+	m := &Manager{
+		workflow: workflow,
+		store:    store,
+	}
+
+	for i := range m.shards {
+		m.shards[i].instances = make(map[string]*managedInstance)
+	}
+
+	return m
+}
+
+func (m *Manager) New(id string, initialState any) (*WorkflowInstance, error) {
+	shard := m.shard(id)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, exists := shard.instances[id]; exists {
+		return nil, ErrInstanceAlreadyExists
+	}
+
+	if m.store != nil {
+		_, err := m.store.Load(id)
+		switch {
+		case err == nil:
+			return nil, ErrInstanceAlreadyExists
+		case !errors.Is(err, ErrInstanceNotFound):
+			return nil, err
+		}
+	}
+
+	instance := m.workflow.New(initialState)
+	instance.id = id
+
+	shard.instances[id] = m.track(instance)
+
+	return instance, nil
+}
+
+// Get returns the instance with the given id, loading it from the Store if
+// it has been evicted. The returned instance must not be retained, since it
+// might be evicted at any time; use Dispatch to continue an instance by id.
+func (m *Manager) Get(id string) (*WorkflowInstance, error) {
+	shard := m.shard(id)
+
+	shard.mu.RLock()
+	entry, exists := shard.instances[id]
+	shard.mu.RUnlock()
+
+	if exists {
+		entry.touch()
+		return entry.WorkflowInstance, nil
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if entry, exists = shard.instances[id]; exists {
+		entry.touch()
+		return entry.WorkflowInstance, nil
+	}
+
+	if m.store == nil {
+		return nil, ErrInstanceNotFound
+	}
+
+	snapshot, err := m.store.Load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := m.workflow.Restore(id, snapshot)
+	shard.instances[id] = m.track(instance)
+
+	return instance, nil
+}
+
+// Dispatch applies the input to the instance with the given id.
+func (m *Manager) Dispatch(id string, input ...any) error {
+	for {
+		instance, err := m.Get(id)
+		if err != nil {
+			return err
+		}
+
+		err = instance.ContinueWith(input...)
+		if errors.Is(err, ErrInstanceEvicted) {
+			continue
+		}
+
+		return err
+	}
+}
+
+// Evict saves the instance with the given id to the Store and removes it
+// from memory. It waits for a transition of the instance that is running.
+func (m *Manager) Evict(id string) error {
+	shard := m.shard(id)
+
+	shard.mu.RLock()
+	entry, exists := shard.instances[id]
+	shard.mu.RUnlock()
+
+	if !exists {
+		return ErrInstanceNotFound
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return m.evict(shard, entry)
+}
+
+// EvictIdle evicts all instances that haven't been used for at least maxIdle
+// and returns how many were evicted. Instances performing a transition are
+// skipped.
+func (m *Manager) EvictIdle(maxIdle time.Duration) (int, error) {
+	if m.store == nil {
+		return 0, nil
+	}
+
+	threshold := m.workflow.getClock().Now().Add(-maxIdle).UnixNano()
+	evicted := 0
+	var errs []error
+
+	for i := range m.shards {
+		shard := &m.shards[i]
+
+		shard.mu.RLock()
+		var idle []*managedInstance
+		for _, entry := range shard.instances {
+			if entry.lastUsed.Load() <= threshold {
+				idle = append(idle, entry)
+			}
+		}
+		shard.mu.RUnlock()
+
+		for _, entry := range idle {
+			if !entry.mu.TryLock() {
+				continue
+			}
+
+			var err error
+			if entry.lastUsed.Load() <= threshold {
+				err = m.evict(shard, entry)
+			} else {
+				err = ErrInstanceBusy
+			}
+			entry.mu.Unlock()
+
+			switch {
+			case errors.Is(err, ErrInstanceBusy), errors.Is(err, ErrInstanceNotFound):
+				continue
+			case err != nil:
+				errs = append(errs, err)
+				continue
+			}
+
+			evicted++
+		}
+	}
+
+	return evicted, errors.Join(errs...)
+}
+
+// Len returns the number of instances currently held in memory.
+func (m *Manager) Len() int {
+	n := 0
+	for i := range m.shards {
+		m.shards[i].mu.RLock()
+		n += len(m.shards[i].instances)
+		m.shards[i].mu.RUnlock()
+	}
+
+	return n
+}
+
+// evict saves the locked instance to the Store and removes it from its
+// shard. The shard is locked only to remove the entry, so that other
+// instances of the shard aren't blocked while the instance is saved.
+func (m *Manager) evict(shard *managerShard, entry *managedInstance) error {
+	if m.store == nil {
+		return ErrManagerHasNoStore
+	}
+
+	instance := entry.WorkflowInstance
+	if instance.evicted {
+		return ErrInstanceNotFound
+	}
+	if instance.hasQueuedInput() {
+		return ErrInstanceBusy
+	}
+
+	if err := m.store.Save(instance.id, instance.snapshot()); err != nil {
+		return err
+	}
+
+	shard.mu.Lock()
+	if shard.instances[instance.id] == entry {
+		delete(shard.instances, instance.id)
+	}
+	shard.mu.Unlock()
+
+	instance.evicted = true
+	instance.stopTimeout()
+
+	return nil
+}
+
+func (m *Manager) track(instance *WorkflowInstance) *managedInstance {
+	instance.manager = m
+
+	entry := &managedInstance{WorkflowInstance: instance}
+	entry.touch()
+
+	return entry
+}
+
+func (m *Manager) shard(id string) *managerShard {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(id))
+
+	return &m.shards[hash.Sum32()%managerShardCount]
+}
+
+func (e *managedInstance) touch() {
+	e.lastUsed.Store(e.workflow.getClock().Now().UnixNano())
+}
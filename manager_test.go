@@ -0,0 +1,243 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newCountingWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransition(func(state int, input string) int {
+		return state + len(input)
+	})
+
+	return w
+}
+
+func TestNewManager(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithValue(t, "workflow must not be nil", func() { NewManager(nil, nil) })
+
+	m := NewManager(newCountingWorkflow(), nil)
+	require.NotNil(t, m)
+	require.Zero(t, m.Len())
+}
+
+func TestManager_New(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	require.NoError(t, store.Save("stored", Snapshot{State: 1}))
+
+	m := NewManager(newCountingWorkflow(), store)
+
+	instance, err := m.New("fresh", 0)
+	require.NoError(t, err)
+	require.Equal(t, "fresh", instance.ID())
+	require.Equal(t, 0, instance.CurrentState())
+
+	_, err = m.New("fresh", 0)
+	require.ErrorIs(t, err, ErrInstanceAlreadyExists)
+
+	_, err = m.New("stored", 0)
+	require.ErrorIs(t, err, ErrInstanceAlreadyExists)
+}
+
+func TestManager_Get(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	require.NoError(t, store.Save("stored", Snapshot{State: 5}))
+
+	m := NewManager(newCountingWorkflow(), store)
+	created, err := m.New("fresh", 0)
+	require.NoError(t, err)
+
+	instance, err := m.Get("fresh")
+	require.NoError(t, err)
+	require.Same(t, created, instance)
+
+	instance, err = m.Get("stored")
+	require.NoError(t, err)
+	require.Equal(t, "stored", instance.ID())
+	require.Equal(t, 5, instance.CurrentState())
+	require.Equal(t, 2, m.Len())
+
+	_, err = m.Get("missing")
+	require.ErrorIs(t, err, ErrInstanceNotFound)
+
+	_, err = NewManager(newCountingWorkflow(), nil).Get("missing")
+	require.ErrorIs(t, err, ErrInstanceNotFound)
+}
+
+func TestManager_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(newCountingWorkflow(), NewMemoryStore())
+	_, err := m.New("a", 0)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Dispatch("a", "abc"))
+	require.ErrorIs(t, m.Dispatch("a", 1), ErrTransitionDoesNotExist)
+	require.ErrorIs(t, m.Dispatch("b", "abc"), ErrInstanceNotFound)
+
+	require.NoError(t, m.Evict("a"))
+	require.Zero(t, m.Len())
+
+	require.NoError(t, m.Dispatch("a", "de"))
+
+	instance, err := m.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, 5, instance.CurrentState())
+}
+
+func TestManager_Evict(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	m := NewManager(newCountingWorkflow(), store)
+	instance, err := m.New("a", 0)
+	require.NoError(t, err)
+	require.NoError(t, instance.ContinueWith("abc"))
+
+	require.NoError(t, m.Evict("a"))
+	require.ErrorIs(t, m.Evict("a"), ErrInstanceNotFound)
+	require.ErrorIs(t, instance.ContinueWith("abc"), ErrInstanceEvicted)
+
+	snapshot, err := store.Load("a")
+	require.NoError(t, err)
+	require.Equal(t, 3, snapshot.State)
+
+	withoutStore := NewManager(newCountingWorkflow(), nil)
+	_, err = withoutStore.New("a", 0)
+	require.NoError(t, err)
+	require.ErrorIs(t, withoutStore.Evict("a"), ErrManagerHasNoStore)
+}
+
+type failingStore struct {
+	*MemoryStore
+}
+
+func (f failingStore) Save(string, Snapshot) error {
+	return errors.New("store unavailable")
+}
+
+func TestManager_EvictIdle(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(newCountingWorkflow(), NewMemoryStore())
+	for i := 0; i < 10; i++ {
+		_, err := m.New(fmt.Sprint(i), i)
+		require.NoError(t, err)
+	}
+
+	evicted, err := m.EvictIdle(time.Hour)
+	require.NoError(t, err)
+	require.Zero(t, evicted)
+	require.Equal(t, 10, m.Len())
+
+	evicted, err = m.EvictIdle(0)
+	require.NoError(t, err)
+	require.Equal(t, 10, evicted)
+	require.Zero(t, m.Len())
+
+	failing := NewManager(newCountingWorkflow(), failingStore{NewMemoryStore()})
+	_, err = failing.New("a", 0)
+	require.NoError(t, err)
+
+	evicted, err = failing.EvictIdle(0)
+	require.Error(t, err)
+	require.Zero(t, evicted)
+	require.Equal(t, 1, failing.Len())
+}
+
+func TestManager_EvictIdle_busy(t *testing.T) {
+	t.Parallel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	w := NewWorkflow()
+	w.AddTransition(func(state int, input string) int {
+		close(started)
+		<-release
+		return state + 1
+	})
+
+	m := NewManager(w, NewMemoryStore())
+
+	// Find another id in the same shard as the busy instance
+	other := ""
+	for i := 0; other == ""; i++ {
+		if id := fmt.Sprint(i); m.shard(id) == m.shard("a") {
+			other = id
+		}
+	}
+
+	busy, err := m.New("a", 0)
+	require.NoError(t, err)
+	_, err = m.New(other, 0)
+	require.NoError(t, err)
+
+	go func() { _ = busy.ContinueWith("x") }()
+	<-started
+
+	evicted, err := m.EvictIdle(0)
+	require.NoError(t, err)
+	require.Equal(t, 1, evicted)
+
+	_, err = m.Get(other)
+	require.NoError(t, err)
+
+	close(release)
+	require.Eventually(t, func() bool { return busy.CurrentState() == 1 }, waitFor, tick)
+	require.NoError(t, m.Evict("a"))
+}
+
+func TestManager_Dispatch_concurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numberOfInstances = 50
+		numberOfInputs    = 20
+	)
+
+	m := NewManager(newCountingWorkflow(), NewMemoryStore())
+	for i := 0; i < numberOfInstances; i++ {
+		_, err := m.New(fmt.Sprint(i), 0)
+		require.NoError(t, err)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(numberOfInstances + 1)
+
+	for i := 0; i < numberOfInstances; i++ {
+		go func(id string) {
+			defer wg.Done()
+			for j := 0; j < numberOfInputs; j++ {
+				require.NoError(t, m.Dispatch(id, "x"))
+			}
+		}(fmt.Sprint(i))
+	}
+
+	go func() {
+		defer wg.Done()
+		for j := 0; j < numberOfInputs; j++ {
+			_, err := m.EvictIdle(0)
+			require.NoError(t, err)
+		}
+	}()
+
+	wg.Wait()
+
+	for i := 0; i < numberOfInstances; i++ {
+		instance, err := m.Get(fmt.Sprint(i))
+		require.NoError(t, err)
+		require.Equal(t, numberOfInputs, instance.CurrentState())
+	}
+}
@@ -0,0 +1,120 @@
+package ekstatic
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+type (
+	// Parallel is a composite state made of regions that advance
+	// independently. Inputs are applied to every region that has a
+	// transition for them. When a state embeds a *Parallel, inputs its
+	// workflow has no transition for are forwarded to the regions, and once
+	// all of them are final, RegionsCompleted is applied to the state.
+	Parallel struct {
+		regions []*WorkflowInstance
+
+		mu sync.Mutex
+	}
+
+	// RegionsCompleted holds the final states of the regions of a Parallel,
+	// in the order the regions were passed to NewParallel.
+	RegionsCompleted struct {
+		States []any
+	}
+)
+
+func NewParallel(regions ...*WorkflowInstance) *Parallel {
+	for _, region := range regions {
+		if region == nil {
+			panic("region must not be nil")
+		}
+	}
+
+	return &Parallel{regions: regions}
+}
+
+// ContinueWith applies the input to all regions with a transition for it.
+// It returns ErrTransitionDoesNotExist if there is no such region, and the
+// errors of the failed transitions otherwise.
+func (p *Parallel) ContinueWith(input ...any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handled := false
+	var errs []error
+
+	for _, region := range p.regions {
+		err := region.ContinueWith(input...)
+		if errors.Is(err, ErrTransitionDoesNotExist) {
+			continue
+		}
+
+		handled = true
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if !handled {
+		return ErrTransitionDoesNotExist
+	}
+
+	return errors.Join(errs...)
+}
+
+// CurrentState returns the current states of all regions.
+func (p *Parallel) CurrentState() []any {
+	states := make([]any, len(p.regions))
+	for i, region := range p.regions {
+		states[i] = region.CurrentState()
+	}
+
+	return states
+}
+
+func (p *Parallel) Regions() []*WorkflowInstance {
+	return p.regions
+}
+
+// IsFinal reports whether all regions are in a final state.
+func (p *Parallel) IsFinal() bool {
+	_, isFinal := p.completion()
+	return isFinal
+}
+
+func (p *Parallel) forward(input ...any) error {
+	if p == nil {
+		return ErrTransitionDoesNotExist
+	}
+
+	return p.ContinueWith(input...)
+}
+
+func (p *Parallel) canForward(input ...any) bool {
+	if p == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(p.regions, func(region *WorkflowInstance) bool {
+		return region.CanContinueWith(input...)
+	})
+}
+
+func (p *Parallel) completion() (any, bool) {
+	if p == nil {
+		return nil, false
+	}
+
+	states := make([]any, len(p.regions))
+	for i, region := range p.regions {
+		state, isFinal := region.completion()
+		if !isFinal {
+			return nil, false
+		}
+		states[i] = state
+	}
+
+	return RegionsCompleted{states}, true
+}
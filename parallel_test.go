@@ -0,0 +1,101 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	statePaymentPending struct{}
+	statePaymentDone    struct{}
+	stateShippingPacked struct{}
+	stateShippingSent   string
+	stateOrderOpen      struct {
+		*Parallel
+	}
+	stateOrderDone []any
+
+	inputPay      struct{}
+	inputShip     string
+	inputCancel   struct{}
+	inputRegional struct{}
+)
+
+func newOrderRegions() (payment, shipping *WorkflowInstance) {
+	paymentWorkflow := NewWorkflow()
+	paymentWorkflow.AddTransitions(
+		func(statePaymentPending, inputPay) statePaymentDone { return statePaymentDone{} },
+		func(statePaymentPending, inputRegional) (statePaymentPending, error) {
+			return statePaymentPending{}, errors.New("payment failed")
+		},
+	)
+	paymentWorkflow.AddFinalStates(statePaymentDone{})
+
+	shippingWorkflow := NewWorkflow()
+	shippingWorkflow.AddTransitions(
+		func(s stateShippingPacked, i inputShip) stateShippingSent { return stateShippingSent(i) },
+		func(stateShippingPacked, inputRegional) stateShippingPacked { return stateShippingPacked{} },
+	)
+	shippingWorkflow.AddFinalStates(stateShippingSent(""))
+
+	return paymentWorkflow.New(statePaymentPending{}), shippingWorkflow.New(stateShippingPacked{})
+}
+
+func TestNewParallel(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithValue(t, "region must not be nil", func() { NewParallel(nil) })
+
+	payment, shipping := newOrderRegions()
+	p := NewParallel(payment, shipping)
+	require.Equal(t, []*WorkflowInstance{payment, shipping}, p.Regions())
+	require.Equal(t, []any{statePaymentPending{}, stateShippingPacked{}}, p.CurrentState())
+}
+
+func TestParallel_ContinueWith(t *testing.T) {
+	t.Parallel()
+
+	payment, shipping := newOrderRegions()
+	p := NewParallel(payment, shipping)
+
+	require.ErrorIs(t, p.ContinueWith(inputCancel{}), ErrTransitionDoesNotExist)
+
+	err := p.ContinueWith(inputRegional{})
+	require.EqualError(t, err, "payment failed")
+
+	require.NoError(t, p.ContinueWith(inputShip("parcel")))
+	require.Equal(t, []any{statePaymentPending{}, stateShippingSent("parcel")}, p.CurrentState())
+	require.False(t, p.IsFinal())
+
+	require.NoError(t, p.ContinueWith(inputPay{}))
+	require.Equal(t, []any{statePaymentDone{}, stateShippingSent("parcel")}, p.CurrentState())
+	require.True(t, p.IsFinal())
+}
+
+func TestWorkflowInstance_ContinueWith_parallel(t *testing.T) {
+	t.Parallel()
+
+	orderWorkflow := NewWorkflow()
+	orderWorkflow.AddTransitions(
+		func(s stateOrderOpen, r RegionsCompleted) stateOrderDone { return r.States },
+		func(s stateOrderOpen, c inputCancel) stateOrderDone { return nil },
+	)
+
+	payment, shipping := newOrderRegions()
+	order := orderWorkflow.New(stateOrderOpen{NewParallel(payment, shipping)})
+
+	require.NoError(t, order.ContinueWith(inputPay{}))
+	require.IsType(t, stateOrderOpen{}, order.CurrentState())
+
+	require.NoError(t, order.ContinueWith(inputShip("parcel")))
+	require.Equal(t, stateOrderDone{statePaymentDone{}, stateShippingSent("parcel")}, order.CurrentState())
+
+	payment, shipping = newOrderRegions()
+	cancelled := orderWorkflow.New(stateOrderOpen{NewParallel(payment, shipping)})
+	require.NoError(t, cancelled.ContinueWith(inputCancel{}))
+	require.Equal(t, stateOrderDone(nil), cancelled.CurrentState())
+
+	require.ErrorIs(t, orderWorkflow.New(stateOrderOpen{}).ContinueWith(inputPay{}), ErrTransitionDoesNotExist)
+}
@@ -0,0 +1,155 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"time"
+)
+
+// maxRetryBackoff caps the time to wait between attempts of a transition,
+// since the instance is locked while waiting.
+const maxRetryBackoff = 5 * time.Second
+
+type (
+	// RetryPolicy configures how often a failing transition is attempted
+	// and how long to wait between attempts. The failure action is performed
+	// only once all attempts have failed. A transition keeps its instance
+	// locked between attempts, so that CurrentState, Snapshot, timeouts and
+	// the mailbox of the instance wait for them, and its backoffs are capped
+	// at five seconds. Inputs that need longer backoffs should be scheduled
+	// with ContinueAfter, whose deliveries follow the redelivery policy.
+	RetryPolicy struct {
+		// MaxAttempts is the number of attempts including the first one.
+		MaxAttempts int
+
+		// InitialBackoff is the time to wait before the second attempt. It
+		// is multiplied by Multiplier, or 2 if it is zero, for each further
+		// attempt, up to MaxBackoff if that isn't zero.
+		InitialBackoff time.Duration
+		MaxBackoff     time.Duration
+		Multiplier     float64
+
+		// Jitter randomly varies each backoff by up to the given fraction.
+		Jitter float64
+
+		// Retryable decides whether an error is worth another attempt. If it
+		// is nil, all errors are.
+		Retryable func(err error) bool
+	}
+
+	// RetriesExhaustedError is returned when a transition failed on every
+	// attempt of its RetryPolicy.
+	RetriesExhaustedError struct {
+		Attempts int
+		Err      error
+	}
+)
+
+// WithRetry sets the retry policy of the transition, replacing the one of
+// the workflow.
+func WithRetry(policy RetryPolicy) TransitionOption {
+	return func(c *transitionConfig) {
+		c.retryPolicy = &policy
+	}
+}
+
+// SetRetryPolicy sets the retry policy for all transitions of the workflow
+// that don't have their own.
+func (w *Workflow) SetRetryPolicy(policy RetryPolicy) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.retryPolicy = policy
+}
+
+// RetryOn returns a predicate for RetryPolicy.Retryable that accepts all
+// errors matching one of the targets according to errors.Is.
+func RetryOn(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("transition failed after %d attempts: %s", e.Attempts, e.Err.Error())
+}
+
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+func (w *Workflow) retryPolicyFor(identifier transitionIdentifer) RetryPolicy {
+	defer w.rlock()()
+
+	if config, exists := w.transitionConfigs[identifier]; exists && config.retryPolicy != nil {
+		return *config.retryPolicy
+	}
+
+	return w.retryPolicy
+}
+
+// call performs the transition, retrying it according to its RetryPolicy.
+// Inputs emitted by failed attempts are discarded.
+func (w *WorkflowInstance) call(identifier transitionIdentifer, transition reflect.Value, args []reflect.Value) ([]reflect.Value, error) {
+	policy := w.workflow.retryPolicyFor(identifier)
+	emitted := len(w.emitted)
+
+	for attempt := 1; ; attempt++ {
+		result := transition.Call(args)
+
+		if result[0].Interface() == nil {
+			panic("transition returned nil as result state")
+		}
+
+		if len(result) < 2 || result[1].IsNil() {
+			if !w.workflow.isTarget(identifier, result[0].Interface()) {
+				w.emitted = w.emitted[:emitted]
+				return result, ErrUnexpectedTargetState
+			}
+			return result, nil
+		}
+
+		err := result[1].Interface().(error)
+		w.emitted = w.emitted[:emitted]
+
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			if attempt > 1 {
+				err = &RetriesExhaustedError{attempt, err}
+			}
+			return result, err
+		}
+
+		w.workflow.getClock().Sleep(min(policy.backoff(attempt), maxRetryBackoff))
+	}
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// backoff returns the time to wait after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 {
+		backoff = math.Min(backoff, float64(p.MaxBackoff))
+	}
+
+	if p.Jitter > 0 {
+		backoff *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(backoff)
+}
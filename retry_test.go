@@ -0,0 +1,185 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTemporary = errors.New("temporary failure")
+	errPermanent = errors.New("permanent failure")
+)
+
+// newFlakyWorkflow returns a workflow whose transition fails with the given
+// errors before it succeeds, and a pointer to the number of attempts made.
+func newFlakyWorkflow(clock Clock, errs ...error) (*Workflow, *int) {
+	attempts := 0
+
+	w := NewWorkflow()
+	w.SetClock(clock)
+	w.AddTransition(func(state string, input string, e Emitter) (string, error) {
+		attempts++
+		e.Emit(1)
+		if attempts <= len(errs) {
+			return "", errs[attempts-1]
+		}
+		return state + input, nil
+	})
+	w.AddTransition(func(state string, input int) string { return state + "!" })
+
+	return w, &attempts
+}
+
+func TestRetryOn(t *testing.T) {
+	t.Parallel()
+
+	retryable := RetryOn(errTemporary)
+	require.True(t, retryable(errTemporary))
+	require.True(t, retryable(errors.Join(errTemporary, errPermanent)))
+	require.False(t, retryable(errPermanent))
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, policy.backoff(1))
+	require.Equal(t, 2*time.Second, policy.backoff(2))
+	require.Equal(t, 4*time.Second, policy.backoff(3))
+	require.Equal(t, 5*time.Second, policy.backoff(4))
+
+	policy = RetryPolicy{InitialBackoff: time.Second, Multiplier: 3, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(2)
+		require.GreaterOrEqual(t, backoff, 1500*time.Millisecond)
+		require.LessOrEqual(t, backoff, 4500*time.Millisecond)
+	}
+}
+
+func TestWorkflowInstance_ContinueWith_retry(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		policy           RetryPolicy
+		errs             []error
+		err              error
+		attempts         int
+		slept            time.Duration
+		destinationState string
+	}{
+		{
+			name:             "succeeds after retries",
+			policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second},
+			errs:             []error{errTemporary, errTemporary},
+			attempts:         3,
+			slept:            3 * time.Second,
+			destinationState: "a!",
+		},
+		{
+			name:             "retries exhausted",
+			policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second},
+			errs:             []error{errTemporary, errTemporary, errTemporary},
+			err:              &RetriesExhaustedError{3, errTemporary},
+			attempts:         3,
+			slept:            3 * time.Second,
+			destinationState: "",
+		},
+		{
+			name:             "error isn't retryable",
+			policy:           RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, Retryable: RetryOn(errTemporary)},
+			errs:             []error{errTemporary, errPermanent},
+			err:              &RetriesExhaustedError{2, errPermanent},
+			attempts:         2,
+			slept:            time.Second,
+			destinationState: "",
+		},
+		{
+			name:             "backoff is capped",
+			policy:           RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour},
+			errs:             []error{errTemporary},
+			attempts:         2,
+			slept:            maxRetryBackoff,
+			destinationState: "a!",
+		},
+		{
+			name:             "no retry policy",
+			errs:             []error{errTemporary},
+			err:              errTemporary,
+			attempts:         1,
+			destinationState: "",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			clock := NewManualClock(testEpoch)
+			w, attempts := newFlakyWorkflow(clock, tt.errs...)
+			w.SetRetryPolicy(tt.policy)
+
+			failures := 0
+			w.AddTransitionFailedAction(func(err error, previousState any, input ...any) {
+				failures++
+				require.Equal(t, tt.err, err)
+			})
+
+			instance := w.New("")
+			err := instance.ContinueWith("a")
+
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.attempts, *attempts)
+			require.Equal(t, testEpoch.Add(tt.slept), clock.Now())
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+
+			if tt.err != nil {
+				require.Equal(t, 1, failures)
+				require.ErrorIs(t, err, tt.errs[len(tt.errs)-1])
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	w, attempts := newFlakyWorkflow(clock, errTemporary, errTemporary)
+	w.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+
+	instance := w.New("")
+	require.Error(t, instance.ContinueWith("a"))
+	require.Equal(t, 2, *attempts)
+
+	clock = NewManualClock(testEpoch)
+	w = NewWorkflow()
+	w.SetClock(clock)
+	w.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+
+	*attempts = 0
+	w.AddTransition(func(state string, input string) (string, error) {
+		*attempts++
+		if *attempts < 4 {
+			return "", errTemporary
+		}
+		return state + input, nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Second}))
+
+	instance = w.New("")
+	require.NoError(t, instance.ContinueWith("a"))
+	require.Equal(t, 4, *attempts)
+	require.Equal(t, testEpoch.Add(7*time.Second), clock.Now())
+}
+
+func TestRetriesExhaustedError(t *testing.T) {
+	t.Parallel()
+
+	err := &RetriesExhaustedError{3, errTemporary}
+	require.EqualError(t, err, "transition failed after 3 attempts: temporary failure")
+	require.ErrorIs(t, err, errTemporary)
+}
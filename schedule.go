@@ -0,0 +1,389 @@
+package ekstatic
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var defaultRedeliveryPolicy = RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+
+type (
+	// KeyedInput is implemented by inputs that carry a key identifying them,
+	// e.g. the id of the message they originate from.
+	KeyedInput interface {
+		IdempotencyKey() string
+	}
+
+	// ScheduledInput is an input that is going to be applied to an instance
+	// at a given time.
+	ScheduledInput struct {
+		Key        string
+		InstanceID string
+		Due        time.Time
+		Input      []any
+
+		// Attempts is the number of failed attempts to deliver the input.
+		Attempts int
+	}
+
+	// TimerStore persists the inputs scheduled for instances of a Manager
+	// until they have been delivered. A scheduled input is deleted only
+	// after it has been applied or given up on, so that inputs that are
+	// pending while the process stops are delivered after the Manager has
+	// been resumed. If applying it fails, it is put again with its new due
+	// time and number of attempts.
+	TimerStore interface {
+		Put(scheduled ScheduledInput) error
+		Delete(key string) error
+		Pending() ([]ScheduledInput, error)
+	}
+
+	ScheduleHandle struct {
+		ScheduledInput
+
+		scheduler *scheduler
+		timer     Timer
+		stored    bool
+	}
+
+	scheduler struct {
+		workflow *Workflow
+		pending  map[string]*ScheduleHandle
+		writing  map[string]*keyLock
+
+		mu sync.Mutex
+	}
+
+	// keyLock orders the writes to the TimerStore for a key.
+	keyLock struct {
+		mu    sync.Mutex
+		users int
+	}
+
+	MemoryTimerStore struct {
+		scheduled map[string]ScheduledInput
+
+		mu sync.RWMutex
+	}
+)
+
+// SetTimerStore sets the store that inputs scheduled with ContinueAt and
+// ContinueAfter are persisted to.
+func (w *Workflow) SetTimerStore(store TimerStore) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.timerStore = store
+}
+
+// SetRedeliveryPolicy sets how often the delivery of a scheduled input is
+// attempted and how long to wait between attempts. By default, it is
+// attempted up to ten times, with a backoff growing from a second to a
+// minute. Inputs failing with ErrTransitionDoesNotExist or
+// ErrInstanceNotFound are never delivered again.
+func (w *Workflow) SetRedeliveryPolicy(policy RetryPolicy) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	w.redeliveryPolicy = &policy
+}
+
+func (w *Workflow) getRedeliveryPolicy() RetryPolicy {
+	defer w.rlock()()
+
+	if w.redeliveryPolicy == nil {
+		return defaultRedeliveryPolicy
+	}
+
+	return *w.redeliveryPolicy
+}
+
+func (w *Workflow) getScheduler() *scheduler {
+	w.schedulerOnce.Do(func() {
+		w.scheduler = &scheduler{
+			workflow: w,
+			pending:  make(map[string]*ScheduleHandle),
+			writing:  make(map[string]*keyLock),
+		}
+	})
+
+	return w.scheduler
+}
+
+// ContinueAt applies the input to the instance at the given time. If one of
+// the inputs is a KeyedInput, scheduling it again before it has been
+// delivered returns the handle of the input scheduled first. If applying the
+// input fails, it is delivered again according to the redelivery policy of
+// the workflow. Only inputs of instances created by a Manager are persisted
+// to the TimerStore, since other instances can't be resumed.
+func (w *WorkflowInstance) ContinueAt(at time.Time, input ...any) (*ScheduleHandle, error) {
+	owner := w.id
+	if w.manager == nil {
+		// Instances not created by a Manager are told apart by their address
+		owner = fmt.Sprintf("%p", w)
+	}
+	key := owner + "/" + scheduleKey(input)
+
+	return w.workflow.getScheduler().schedule(ScheduledInput{
+		Key:        key,
+		InstanceID: w.id,
+		Due:        at,
+		Input:      input,
+	}, w.deliver, w.manager != nil, true)
+}
+
+// ContinueAfter applies the input to the instance after the given duration.
+func (w *WorkflowInstance) ContinueAfter(d time.Duration, input ...any) (*ScheduleHandle, error) {
+	return w.ContinueAt(w.workflow.getClock().Now().Add(d), input...)
+}
+
+func (w *WorkflowInstance) deliver(input ...any) error {
+	if w.manager != nil {
+		return w.manager.Dispatch(w.id, input...)
+	}
+
+	return w.ContinueWith(input...)
+}
+
+// ResumeScheduled schedules the delivery of all inputs that are pending in
+// the TimerStore of the workflow, e.g. after a restart of the process.
+func (m *Manager) ResumeScheduled() error {
+	if m.workflow.timerStore == nil {
+		return nil
+	}
+
+	pending, err := m.workflow.timerStore.Pending()
+	if err != nil {
+		return err
+	}
+
+	s := m.workflow.getScheduler()
+	for _, scheduled := range pending {
+		deliver := func(input ...any) error { return m.Dispatch(scheduled.InstanceID, input...) }
+		if _, err := s.schedule(scheduled, deliver, true, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Cancel stops the delivery of the scheduled input.
+func (h *ScheduleHandle) Cancel() error {
+	return h.scheduler.cancel(h)
+}
+
+// schedule starts the timer of the scheduled input. Inputs that are stored
+// are persisted to the TimerStore, unless they have been loaded from it.
+func (s *scheduler) schedule(scheduled ScheduledInput, deliver func(input ...any) error, stored, persist bool) (*ScheduleHandle, error) {
+	s.mu.Lock()
+	if handle, exists := s.pending[scheduled.Key]; exists {
+		s.mu.Unlock()
+		return handle, nil
+	}
+
+	handle := &ScheduleHandle{
+		ScheduledInput: scheduled,
+		scheduler:      s,
+		stored:         stored,
+	}
+	s.pending[scheduled.Key] = handle
+	s.mu.Unlock()
+
+	if persist {
+		if err := s.persist(handle.Key); err != nil {
+			s.mu.Lock()
+			if s.pending[handle.Key] == handle {
+				delete(s.pending, handle.Key)
+			}
+			s.mu.Unlock()
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending[handle.Key] == handle {
+		clock := s.workflow.getClock()
+		handle.timer = clock.AfterFunc(scheduled.Due.Sub(clock.Now()), func() { s.deliver(handle, deliver) })
+	}
+
+	return handle, nil
+}
+
+func (s *scheduler) deliver(handle *ScheduleHandle, deliver func(input ...any) error) {
+	s.mu.Lock()
+	if s.pending[handle.Key] != handle {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	err := deliver(handle.Input...)
+
+	s.mu.Lock()
+	if s.pending[handle.Key] != handle {
+		// Cancelled while being delivered
+		s.mu.Unlock()
+		return
+	}
+
+	if err != nil && s.redeliverable(handle, err) {
+		s.redeliver(handle, deliver)
+	} else {
+		delete(s.pending, handle.Key)
+	}
+	s.mu.Unlock()
+
+	// The store keeps the entry as it was before if this fails
+	_ = s.persist(handle.Key)
+}
+
+// redeliverable reports whether another attempt to deliver the input is made
+// after it failed with err.
+func (s *scheduler) redeliverable(handle *ScheduleHandle, err error) bool {
+	if errors.Is(err, ErrTransitionDoesNotExist) || errors.Is(err, ErrInstanceNotFound) {
+		return false
+	}
+
+	policy := s.workflow.getRedeliveryPolicy()
+
+	return handle.Attempts+1 < policy.MaxAttempts && policy.retryable(err)
+}
+
+// redeliver schedules another attempt to deliver the input after its
+// delivery failed.
+func (s *scheduler) redeliver(handle *ScheduleHandle, deliver func(input ...any) error) {
+	clock := s.workflow.getClock()
+
+	handle.Attempts++
+	backoff := s.workflow.getRedeliveryPolicy().backoff(handle.Attempts)
+	handle.Due = clock.Now().Add(backoff)
+
+	handle.timer = clock.AfterFunc(backoff, func() { s.deliver(handle, deliver) })
+}
+
+func (s *scheduler) cancel(handle *ScheduleHandle) error {
+	s.mu.Lock()
+	if s.pending[handle.Key] != handle {
+		s.mu.Unlock()
+		return nil
+	}
+
+	if handle.timer != nil {
+		handle.timer.Stop()
+	}
+	delete(s.pending, handle.Key)
+	s.mu.Unlock()
+
+	return s.persist(handle.Key)
+}
+
+// persist writes the scheduled input with the given key to the TimerStore
+// if it is pending and stored, or deletes it otherwise. Writes for the same
+// key are made one at a time, so that the last one reflects the latest
+// change, without making other keys wait for the store.
+func (s *scheduler) persist(key string) error {
+	store := s.workflow.timerStore
+	if store == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	lock, exists := s.writing[key]
+	if !exists {
+		lock = &keyLock{}
+		s.writing[key] = lock
+	}
+	lock.users++
+	s.mu.Unlock()
+
+	lock.mu.Lock()
+	defer func() {
+		lock.mu.Unlock()
+
+		s.mu.Lock()
+		if lock.users--; lock.users == 0 {
+			delete(s.writing, key)
+		}
+		s.mu.Unlock()
+	}()
+
+	s.mu.Lock()
+	handle, pending := s.pending[key]
+	var scheduled ScheduledInput
+	if pending {
+		scheduled = handle.ScheduledInput
+	}
+	s.mu.Unlock()
+
+	switch {
+	case pending && !handle.stored:
+		return nil
+	case pending:
+		return store.Put(scheduled)
+	}
+
+	return store.Delete(key)
+}
+
+func scheduleKey(input []any) string {
+	if key, isKeyed := inputKey(input); isKeyed {
+		return key
+	}
+
+	random := make([]byte, 16)
+	_, _ = rand.Read(random)
+
+	return hex.EncodeToString(random)
+}
+
+func NewMemoryTimerStore() *MemoryTimerStore {
+	return &MemoryTimerStore{
+		scheduled: make(map[string]ScheduledInput),
+	}
+}
+
+func (s *MemoryTimerStore) Put(scheduled ScheduledInput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scheduled[scheduled.Key] = scheduled
+
+	return nil
+}
+
+func (s *MemoryTimerStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.scheduled, key)
+
+	return nil
+}
+
+func (s *MemoryTimerStore) Pending() ([]ScheduledInput, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pending := make([]ScheduledInput, 0, len(s.scheduled))
+	for _, scheduled := range s.scheduled {
+		pending = append(pending, scheduled)
+	}
+
+	slices.SortFunc(pending, func(a, b ScheduledInput) int {
+		if c := a.Due.Compare(b.Due); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return pending, nil
+}
@@ -0,0 +1,305 @@
+package ekstatic
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type keyedInput struct {
+	key   string
+	value string
+}
+
+func (k keyedInput) IdempotencyKey() string {
+	return k.key
+}
+
+func newSchedulingWorkflow(clock Clock, timerStore TimerStore) *Workflow {
+	w := NewWorkflow()
+	w.SetClock(clock)
+	w.SetTimerStore(timerStore)
+	w.AddTransitions(
+		func(state string, input string) string { return state + input },
+		func(state string, input keyedInput) string { return state + input.value },
+	)
+
+	return w
+}
+
+// newManagedInstance returns an instance created by a Manager, since only
+// their scheduled inputs are persisted to the TimerStore.
+func newManagedInstance(t *testing.T, w *Workflow) *WorkflowInstance {
+	instance, err := NewManager(w, nil).New("a", "")
+	require.NoError(t, err)
+
+	return instance
+}
+
+func TestWorkflowInstance_ContinueAt(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	timerStore := NewMemoryTimerStore()
+	instance := newManagedInstance(t, newSchedulingWorkflow(clock, timerStore))
+
+	_, err := instance.ContinueAt(testEpoch.Add(2*time.Second), "b")
+	require.NoError(t, err)
+	_, err = instance.ContinueAt(testEpoch.Add(time.Second), "a")
+	require.NoError(t, err)
+
+	pending, err := timerStore.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.Equal(t, []any{"a"}, pending[0].Input)
+	require.Equal(t, testEpoch.Add(time.Second), pending[0].Due)
+
+	clock.Advance(time.Second)
+	require.Equal(t, "a", instance.CurrentState())
+
+	clock.Advance(time.Second)
+	require.Equal(t, "ab", instance.CurrentState())
+
+	pending, err = timerStore.Pending()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestWorkflowInstance_ContinueAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	instance := newSchedulingWorkflow(clock, nil).New("")
+
+	handle, err := instance.ContinueAfter(time.Minute, "a")
+	require.NoError(t, err)
+	require.Equal(t, testEpoch.Add(time.Minute), handle.Due)
+
+	clock.Advance(59 * time.Second)
+	require.Equal(t, "", instance.CurrentState())
+
+	clock.Advance(time.Second)
+	require.Equal(t, "a", instance.CurrentState())
+}
+
+func TestWorkflowInstance_ContinueAt_deduplicated(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	timerStore := NewMemoryTimerStore()
+	instance := newManagedInstance(t, newSchedulingWorkflow(clock, timerStore))
+
+	first, err := instance.ContinueAfter(time.Second, keyedInput{"msg-1", "a"})
+	require.NoError(t, err)
+	second, err := instance.ContinueAfter(2*time.Second, keyedInput{"msg-1", "a"})
+	require.NoError(t, err)
+	require.Same(t, first, second)
+
+	pending, err := timerStore.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	clock.Advance(time.Minute)
+	require.Equal(t, "a", instance.CurrentState())
+}
+
+func TestWorkflowInstance_ContinueAt_unmanagedInstances(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	timerStore := NewMemoryTimerStore()
+	w := newSchedulingWorkflow(clock, timerStore)
+	a, b := w.New(""), w.New("")
+
+	first, err := a.ContinueAfter(time.Second, keyedInput{"msg-1", "a"})
+	require.NoError(t, err)
+	second, err := b.ContinueAfter(time.Second, keyedInput{"msg-1", "b"})
+	require.NoError(t, err)
+	require.NotSame(t, first, second)
+
+	pending, err := timerStore.Pending()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	clock.Advance(time.Second)
+	require.Equal(t, "a", a.CurrentState())
+	require.Equal(t, "b", b.CurrentState())
+}
+
+func TestWorkflowInstance_ContinueAt_redelivered(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	timerStore := NewMemoryTimerStore()
+
+	failures := 2
+	w := newSchedulingWorkflow(clock, timerStore)
+	w.AddTransition(func(state string, input int) (string, error) {
+		if failures > 0 {
+			failures--
+			return "", errors.New("db timeout")
+		}
+		return state + strconv.Itoa(input), nil
+	})
+
+	instance := newManagedInstance(t, w)
+	_, err := instance.ContinueAfter(time.Second, 1)
+	require.NoError(t, err)
+
+	// Backoffs of one and two seconds follow the failed attempts
+
+	clock.Advance(time.Second)
+	pending, err := timerStore.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, 1, pending[0].Attempts)
+	require.Equal(t, testEpoch.Add(2*time.Second), pending[0].Due)
+
+	clock.Advance(time.Second)
+	pending, err = timerStore.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, 2, pending[0].Attempts)
+	require.Equal(t, "", instance.CurrentState())
+
+	clock.Advance(2 * time.Second)
+	require.Equal(t, "1", instance.CurrentState())
+
+	pending, err = timerStore.Pending()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestWorkflow_SetRedeliveryPolicy(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name     string
+		policy   *RetryPolicy
+		input    any
+		attempts int
+	}{
+		{
+			name:     "default",
+			input:    1,
+			attempts: 10,
+		},
+		{
+			name:     "max attempts",
+			policy:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second},
+			input:    1,
+			attempts: 3,
+		},
+		{
+			name:     "not retryable",
+			policy:   &RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return false }},
+			input:    1,
+			attempts: 1,
+		},
+		{
+			name:     "transition doesn't exist",
+			input:    true,
+			attempts: 0,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			clock := NewManualClock(testEpoch)
+			timerStore := NewMemoryTimerStore()
+
+			attempts := 0
+			w := newSchedulingWorkflow(clock, timerStore)
+			w.AddTransition(func(state string, input int) (string, error) {
+				attempts++
+				return "", errors.New("db timeout")
+			})
+			if tt.policy != nil {
+				w.SetRedeliveryPolicy(*tt.policy)
+			}
+
+			_, err := newManagedInstance(t, w).ContinueAfter(time.Second, tt.input)
+			require.NoError(t, err)
+
+			for i := 0; i < 20; i++ {
+				clock.Advance(time.Minute)
+			}
+
+			require.Equal(t, tt.attempts, attempts)
+
+			pending, err := timerStore.Pending()
+			require.NoError(t, err)
+			require.Empty(t, pending)
+		})
+	}
+}
+
+func TestScheduleHandle_Cancel(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	timerStore := NewMemoryTimerStore()
+	instance := newSchedulingWorkflow(clock, timerStore).New("")
+
+	handle, err := instance.ContinueAfter(time.Second, "a")
+	require.NoError(t, err)
+	require.NoError(t, handle.Cancel())
+	require.NoError(t, handle.Cancel())
+
+	pending, err := timerStore.Pending()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	clock.Advance(time.Minute)
+	require.Equal(t, "", instance.CurrentState())
+}
+
+func TestManager_ResumeScheduled(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	store := NewMemoryStore()
+	timerStore := NewMemoryTimerStore()
+
+	// Schedule inputs with one manager and stop it, as if the process ended.
+
+	stopped := NewManager(newSchedulingWorkflow(clock, timerStore), store)
+	instance, err := stopped.New("a", "")
+	require.NoError(t, err)
+	_, err = instance.ContinueAfter(time.Second, "x")
+	require.NoError(t, err)
+	_, err = instance.ContinueAfter(time.Second, keyedInput{"msg-1", "y"})
+	require.NoError(t, err)
+	require.NoError(t, stopped.Evict("a"))
+
+	// Resume them with a new manager, which delivers them exactly once even
+	// if they are scheduled again.
+
+	resumedClock := NewManualClock(testEpoch)
+	resumed := NewManager(newSchedulingWorkflow(resumedClock, timerStore), store)
+	require.NoError(t, resumed.ResumeScheduled())
+	require.NoError(t, resumed.ResumeScheduled())
+
+	instance, err = resumed.Get("a")
+	require.NoError(t, err)
+	_, err = instance.ContinueAfter(time.Second, keyedInput{"msg-1", "y"})
+	require.NoError(t, err)
+
+	resumedClock.Advance(time.Second)
+
+	instance, err = resumed.Get("a")
+	require.NoError(t, err)
+	require.Len(t, instance.CurrentState(), 2)
+	require.ElementsMatch(t, []rune("xy"), []rune(instance.CurrentState().(string)))
+
+	pending, err := timerStore.Pending()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	require.NoError(t, NewManager(NewWorkflow(), nil).ResumeScheduled())
+}
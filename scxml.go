@@ -0,0 +1,364 @@
+package ekstatic
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	scxmlNamespace    = "http://www.w3.org/2005/07/scxml"
+	ekstaticNamespace = "https://github.com/metamogul/ekstatic"
+)
+
+var (
+	ErrMissingFunction = errors.New("transition doesn't name a function")
+	ErrMissingTargets  = errors.New("transition returns an interface type without declared targets")
+)
+
+type (
+	scxmlTransition struct {
+		event    string
+		targets  []string
+		function string
+	}
+
+	scxmlWriter struct {
+		b           strings.Builder
+		registry    *FunctionRegistry
+		children    map[reflect.Type][]reflect.Type
+		transitions map[reflect.Type][]scxmlTransition
+		final       map[reflect.Type]bool
+	}
+)
+
+// WriteSCXML writes the workflow to wr as a W3C SCXML document starting in
+// the type of initialState. States and inputs are named as registered in
+// the registry, which may be nil, or after their types otherwise. Events
+// are the names of the inputs of a transition separated by commas, and
+// ε-transitions are eventless. Each transition names its function in the
+// attribute function of the ekstatic namespace. Transitions that can lead to
+// several states have the first of them as target, and all of them in the
+// attribute targets of the ekstatic namespace, since SCXML would enter
+// multiple targets at once. Transitions returning an interface type must
+// declare their Targets, or ErrMissingTargets is returned. Substates are
+// nested in their parent states. Parallel regions are only known at runtime
+// and therefore not exported.
+func (w *Workflow) WriteSCXML(wr io.Writer, initialState any, registry *FunctionRegistry) error {
+	if initialState == nil {
+		panic("initial state must not be nil")
+	}
+
+	sw := &scxmlWriter{
+		registry:    registry,
+		children:    make(map[reflect.Type][]reflect.Type),
+		transitions: make(map[reflect.Type][]scxmlTransition),
+		final:       make(map[reflect.Type]bool),
+	}
+
+	states := map[reflect.Type]struct{}{reflect.TypeOf(initialState): {}}
+
+	for _, descriptor := range w.Transitions() {
+		states[descriptor.State] = struct{}{}
+
+		var inputs []string
+		for _, input := range descriptor.Inputs {
+			inputs = append(inputs, registry.typeName(input))
+		}
+
+		transition := scxmlTransition{
+			event:    strings.Join(inputs, ","),
+			function: registry.functionName(descriptor),
+		}
+
+		if len(descriptor.Results) == 0 {
+			return fmt.Errorf("%w: %s", ErrMissingTargets, transition.function)
+		}
+
+		for _, result := range descriptor.Results {
+			states[result] = struct{}{}
+			transition.targets = append(transition.targets, registry.typeName(result))
+		}
+
+		sw.transitions[descriptor.State] = append(sw.transitions[descriptor.State], transition)
+	}
+
+	for state := range states {
+		for _, ancestor := range ancestorTypes(state) {
+			states[ancestor] = struct{}{}
+		}
+	}
+
+	var roots []reflect.Type
+	for state := range states {
+		sw.final[state] = w.isFinal(reflect.New(state).Elem().Interface())
+
+		if parents := ancestorTypes(state); len(parents) > 0 {
+			sw.children[parents[0]] = append(sw.children[parents[0]], state)
+		} else {
+			roots = append(roots, state)
+		}
+	}
+
+	sw.b.WriteString(xml.Header)
+	fmt.Fprintf(&sw.b, "<scxml xmlns=%q xmlns:ekstatic=%q version=\"1.0\" initial=%s>\n",
+		scxmlNamespace, ekstaticNamespace, attr(registry.typeName(reflect.TypeOf(initialState))))
+
+	for _, state := range sw.sorted(roots) {
+		sw.writeState(state, 1)
+	}
+
+	sw.b.WriteString("</scxml>\n")
+
+	_, err := io.WriteString(wr, sw.b.String())
+	return err
+}
+
+func (sw *scxmlWriter) writeState(state reflect.Type, depth int) {
+	var (
+		indent      = strings.Repeat("  ", depth)
+		id          = sw.registry.typeName(state)
+		children    = sw.children[state]
+		transitions = sw.transitions[state]
+	)
+
+	if sw.final[state] && len(children) == 0 && len(transitions) == 0 {
+		fmt.Fprintf(&sw.b, "%s<final id=%s/>\n", indent, attr(id))
+		return
+	}
+
+	fmt.Fprintf(&sw.b, "%s<state id=%s", indent, attr(id))
+	if sw.final[state] {
+		sw.b.WriteString(` ekstatic:final="true"`)
+	}
+	if len(children) == 0 && len(transitions) == 0 {
+		sw.b.WriteString("/>\n")
+		return
+	}
+	sw.b.WriteString(">\n")
+
+	for _, transition := range transitions {
+		fmt.Fprintf(&sw.b, "%s  <transition", indent)
+		if transition.event != "" {
+			fmt.Fprintf(&sw.b, " event=%s", attr(transition.event))
+		}
+		fmt.Fprintf(&sw.b, " target=%s", attr(transition.targets[0]))
+		if len(transition.targets) > 1 {
+			fmt.Fprintf(&sw.b, " ekstatic:targets=%s", attr(strings.Join(transition.targets, " ")))
+		}
+		fmt.Fprintf(&sw.b, " ekstatic:function=%s/>\n", attr(transition.function))
+	}
+
+	for _, child := range sw.sorted(children) {
+		sw.writeState(child, depth+1)
+	}
+
+	fmt.Fprintf(&sw.b, "%s</state>\n", indent)
+}
+
+func (sw *scxmlWriter) sorted(states []reflect.Type) []reflect.Type {
+	sorted := slices.Clone(states)
+	slices.SortFunc(sorted, func(a, b reflect.Type) int {
+		return strings.Compare(sw.registry.typeName(a), sw.registry.typeName(b))
+	})
+
+	return sorted
+}
+
+func attr(value string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+	return `"` + b.String() + `"`
+}
+
+// LoadSCXML creates a workflow from a W3C SCXML document, binding its states,
+// events and transitions to the types and functions of the registry like
+// WorkflowDefinition.Build does. It reads documents written by WriteSCXML, and returns
+// the zero value of the type of the initial state along with the workflow.
+// Transitions take their targets from the attribute targets of the ekstatic
+// namespace if present, and from target otherwise. Transitions from the same
+// state for the same event and function are combined. Elements other than
+// states, final states and transitions are ignored, as is the nesting of
+// states, since the hierarchy of states is defined by their types. All
+// problems found are returned as DefinitionErrors, joined with errors.Join.
+func LoadSCXML(r io.Reader, registry *FunctionRegistry) (*Workflow, any, error) {
+	if registry == nil {
+		panic("function registry must not be nil")
+	}
+
+	var (
+		definition WorkflowDefinition
+		initial    string
+		stack      []string
+		errs       []error
+		inputs     = make(map[string]struct{})
+		states     = make(map[string]int)
+		decoder    = xml.NewDecoder(r)
+	)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrDefinitionMalformed, err)
+		}
+
+		line, _ := decoder.InputPos()
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			// The stack holds the id of the state enclosing each open element
+			enclosing := ""
+			if len(stack) > 0 {
+				enclosing = stack[len(stack)-1]
+			}
+
+			if element.Name.Space != scxmlNamespace {
+				stack = append(stack, enclosing)
+				continue
+			}
+
+			switch element.Name.Local {
+			case "scxml":
+				initial = attrValue(element, "", "initial")
+
+			case "state", "final":
+				id := attrValue(element, "", "id")
+				if _, exists := states[id]; !exists {
+					states[id] = len(definition.States)
+					definition.States = append(definition.States, StateDefinition{Name: id, Line: line})
+				}
+				if element.Name.Local == "final" || attrValue(element, ekstaticNamespace, "final") == "true" {
+					definition.States[states[id]].Final = true
+				}
+				if initial == "" {
+					initial = id
+				}
+				enclosing = id
+
+			case "transition":
+				targets := attrValue(element, ekstaticNamespace, "targets")
+				if targets == "" {
+					targets = attrValue(element, "", "target")
+				}
+
+				transition := TransitionDefinition{
+					From:     enclosing,
+					To:       strings.Fields(targets),
+					Function: attrValue(element, ekstaticNamespace, "function"),
+					Line:     line,
+				}
+				if event := attrValue(element, "", "event"); event != "" {
+					transition.Input = strings.Split(event, ",")
+				}
+
+				for _, input := range transition.Input {
+					if _, exists := inputs[input]; !exists {
+						inputs[input] = struct{}{}
+						definition.Inputs = append(definition.Inputs, InputDefinition{Name: input, Line: line})
+					}
+				}
+
+				if transition.Function == "" {
+					errs = append(errs, &DefinitionError{line, ErrMissingFunction})
+					break
+				}
+
+				definition.Transitions = mergeSCXMLTransition(definition.Transitions, transition)
+			}
+
+			stack = append(stack, enclosing)
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if len(definition.States) == 0 {
+		return nil, nil, ErrDefinitionEmpty
+	}
+
+	w, err := definition.Build(registry)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	initialType, exists := registry.types[initial]
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownType, initial)
+	}
+
+	return w, reflect.New(initialType).Elem().Interface(), nil
+}
+
+// mergeSCXMLTransition adds the transition, or its targets to a transition
+// from the same state for the same event and function.
+func mergeSCXMLTransition(transitions []TransitionDefinition, transition TransitionDefinition) []TransitionDefinition {
+	for i, other := range transitions {
+		if other.From == transition.From && other.Function == transition.Function && slices.Equal(other.Input, transition.Input) {
+			transitions[i].To = append(transitions[i].To, transition.To...)
+			return transitions
+		}
+	}
+
+	return append(transitions, transition)
+}
+
+func attrValue(element xml.StartElement, space, local string) string {
+	for _, a := range element.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// typeName returns the name the type is registered under, or its name
+// otherwise.
+func (r *FunctionRegistry) typeName(t reflect.Type) string {
+	if r != nil {
+		for _, name := range sortedNames(r.types) {
+			if r.types[name] == t {
+				return name
+			}
+		}
+	}
+
+	return t.String()
+}
+
+// functionName returns the name the function of the transition is
+// registered under, or its name otherwise.
+func (r *FunctionRegistry) functionName(descriptor TransitionDescriptor) string {
+	if r != nil {
+		for _, name := range sortedNames(r.functions) {
+			registered := describeTransition(r.functions[name], transitionConfig{})
+			if registered.Name == descriptor.Name && registered.State == descriptor.State &&
+				slices.Equal(registered.Inputs, descriptor.Inputs) {
+				return name
+			}
+		}
+	}
+
+	return descriptor.Name
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,177 @@
+package ekstatic
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflow_WriteSCXML(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	require.NoError(t, newTicketWorkflow().WriteSCXML(&b, stateTicketNew{}, nil))
+
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<scxml xmlns="http://www.w3.org/2005/07/scxml" xmlns:ekstatic="https://github.com/metamogul/ekstatic" version="1.0" initial="ekstatic.stateTicketNew">
+  <state id="ekstatic.stateTicket">
+    <transition event="ekstatic.inputEscalate" target="ekstatic.stateTicketNew" ekstatic:function="github.com/metamogul/ekstatic.newTicketWorkflow.func4"/>
+    <state id="ekstatic.stateTicketClosed"/>
+    <state id="ekstatic.stateTicketOpen">
+      <transition event="ekstatic.inputAssign" target="ekstatic.stateTicketInProgress" ekstatic:function="github.com/metamogul/ekstatic.newTicketWorkflow.func1"/>
+      <transition event="ekstatic.inputClose" target="ekstatic.stateTicketClosed" ekstatic:function="github.com/metamogul/ekstatic.newTicketWorkflow.func2"/>
+      <state id="ekstatic.stateTicketInProgress">
+        <transition event="ekstatic.inputClose" target="ekstatic.stateTicketClosed" ekstatic:function="github.com/metamogul/ekstatic.newTicketWorkflow.func3"/>
+      </state>
+      <state id="ekstatic.stateTicketNew"/>
+    </state>
+  </state>
+</scxml>
+`, b.String())
+
+	w := NewWorkflow()
+	w.AddTransition(func(s stateCharged, c inputCancel) any { return stateDeclined{} })
+	require.ErrorIs(t, w.WriteSCXML(&b, stateCharged{}, newChargeRegistry()), ErrMissingTargets)
+	require.EqualError(t, w.WriteSCXML(&b, stateCharged{}, nil),
+		"transition returns an interface type without declared targets: github.com/metamogul/ekstatic.TestWorkflow_WriteSCXML.func1")
+
+	require.PanicsWithValue(t, "initial state must not be nil", func() {
+		_ = NewWorkflow().WriteSCXML(&b, nil, nil)
+	})
+}
+
+func TestLoadSCXML(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name      string
+		file      string
+		endState  any
+		roundTrip string
+	}{
+		{
+			name:      "written by WriteSCXML",
+			file:      "testdata/charge.scxml",
+			endState:  stateDeclined{},
+			roundTrip: "testdata/charge.scxml",
+		},
+		{
+			name:     "written by a modelling tool",
+			file:     "testdata/charge_modeller.scxml",
+			endState: stateCharging{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			document, err := os.ReadFile(tt.file)
+			require.NoError(t, err)
+
+			w, initialState, err := LoadSCXML(bytes.NewReader(document), newChargeRegistry())
+			require.NoError(t, err)
+			require.Equal(t, stateCharging{}, initialState)
+
+			instance := w.New(initialState)
+			require.EqualError(t, instance.ContinueWith(inputCharge(200)), "limit exceeded")
+			require.NoError(t, instance.ContinueWith(inputCharge(10)))
+			require.NoError(t, instance.ContinueWith(inputCancel{}))
+			require.Equal(t, tt.endState, instance.CurrentState())
+
+			var exported bytes.Buffer
+			require.NoError(t, w.WriteSCXML(&exported, initialState, newChargeRegistry()))
+
+			if tt.roundTrip != "" {
+				expected, err := os.ReadFile(tt.roundTrip)
+				require.NoError(t, err)
+				require.Equal(t, string(expected), exported.String())
+			}
+
+			reloaded, _, err := LoadSCXML(bytes.NewReader(exported.Bytes()), newChargeRegistry())
+			require.NoError(t, err)
+
+			var reexported bytes.Buffer
+			require.NoError(t, reloaded.WriteSCXML(&reexported, initialState, newChargeRegistry()))
+			require.Equal(t, exported.String(), reexported.String())
+		})
+	}
+}
+
+func TestLoadSCXML_errors(t *testing.T) {
+	t.Parallel()
+
+	const header = `<scxml xmlns="http://www.w3.org/2005/07/scxml" xmlns:ekstatic="https://github.com/metamogul/ekstatic" version="1.0"`
+
+	testcases := []struct {
+		name     string
+		document string
+		errs     []error
+		message  string
+	}{
+		{
+			name:     "empty",
+			document: header + "/>",
+			errs:     []error{ErrDefinitionEmpty},
+		},
+		{
+			name:     "malformed",
+			document: header + "><state>",
+			errs:     []error{ErrDefinitionMalformed},
+		},
+		{
+			name: "missing function and unknown type",
+			document: header + `>
+  <state id="Charging">
+    <transition event="Charge" target="Charged"/>
+  </state>
+  <state id="Paid"/>
+</scxml>`,
+			errs:    []error{ErrMissingFunction, ErrUnknownType},
+			message: "line 3: transition doesn't name a function\nline 5: type isn't registered: Paid",
+		},
+		{
+			name: "signature mismatch",
+			document: header + `>
+  <state id="Charging">
+    <transition event="Cancel" target="Charged" ekstatic:function="charge"/>
+  </state>
+  <state id="Charged"/>
+</scxml>`,
+			errs: []error{ErrSignatureMismatch},
+			message: "line 3: function signature doesn't match the declared transition: " +
+				"charge accepts input ekstatic.inputCharge instead of ekstatic.inputCancel",
+		},
+		{
+			name:     "unknown initial state",
+			document: header + ` initial="Paid"><state id="Charging"/></scxml>`,
+			errs:     []error{ErrUnknownType},
+			message:  "type isn't registered: Paid",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			w, initialState, err := LoadSCXML(strings.NewReader(tt.document), newChargeRegistry())
+			require.Nil(t, w)
+			require.Nil(t, initialState)
+			for _, target := range tt.errs {
+				require.ErrorIs(t, err, target)
+			}
+			if tt.message != "" {
+				require.EqualError(t, err, tt.message)
+			}
+		})
+	}
+
+	require.PanicsWithValue(t, "function registry must not be nil", func() {
+		_, _, _ = LoadSCXML(strings.NewReader(""), nil)
+	})
+}
@@ -0,0 +1,138 @@
+package ekstatic
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// Store persists the snapshots of workflow instances that have been
+	// evicted by a Manager. Load must return ErrInstanceNotFound if there
+	// is no snapshot for the given id.
+	Store interface {
+		Save(id string, snapshot Snapshot) error
+		Load(id string) (Snapshot, error)
+	}
+
+	Snapshot struct {
+		State any
+
+		// TimeoutDeadline is when the timeout of the current state
+		// expires, or zero if there is none running.
+		TimeoutDeadline time.Time
+
+		// ProcessedInputs are the keys of the most recently applied
+		// inputs, oldest first.
+		ProcessedInputs []ProcessedInput
+
+		// SubstateHistory holds the last active substates of the parent
+		// states the instance has left, by parent state type.
+		SubstateHistory map[string]SubstateHistory
+
+		// History holds the history entries the instance keeps, oldest
+		// first.
+		History []HistoryEntry
+	}
+
+	// MemoryStore keeps snapshots in memory. It is also a HistorySink
+	// keeping all history entries it receives.
+	MemoryStore struct {
+		snapshots map[string]Snapshot
+		history   map[string][]HistoryEntry
+
+		mu sync.RWMutex
+	}
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		snapshots: make(map[string]Snapshot),
+		history:   make(map[string][]HistoryEntry),
+	}
+}
+
+func (s *MemoryStore) Save(id string, snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[id] = snapshot
+
+	return nil
+}
+
+func (s *MemoryStore) Load(id string) (Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, exists := s.snapshots[id]
+	if !exists {
+		return Snapshot{}, ErrInstanceNotFound
+	}
+
+	return snapshot, nil
+}
+
+func (s *MemoryStore) AppendHistory(id string, entry HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history[id] = append(s.history[id], entry)
+
+	return nil
+}
+
+// History returns all history entries received for the instance with the
+// given id, oldest first.
+func (s *MemoryStore) History(id string) []HistoryEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.history[id])
+}
+
+// Snapshot returns the persistable data of the instance.
+func (w *WorkflowInstance) Snapshot() Snapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.snapshot()
+}
+
+func (w *WorkflowInstance) snapshot() Snapshot {
+	snapshot := Snapshot{
+		State:           w.currentState,
+		ProcessedInputs: w.processed.list(),
+		SubstateHistory: maps.Clone(w.history),
+		History:         w.trail.list(),
+	}
+
+	if w.timeout != nil {
+		snapshot.TimeoutDeadline = w.timeout.deadline
+	}
+
+	return snapshot
+}
+
+// Restore creates an instance with the given id from a snapshot
+// previously taken from an instance of the same workflow.
+func (w *Workflow) Restore(id string, snapshot Snapshot) *WorkflowInstance {
+	instance := w.newInstance(snapshot.State)
+	instance.id = id
+	instance.history = maps.Clone(snapshot.SubstateHistory)
+
+	for _, entry := range snapshot.History {
+		instance.trail.add(entry, w.historyCapacity)
+	}
+
+	for _, processed := range snapshot.ProcessedInputs {
+		instance.processed.add(processed.Key, w.getIdempotencyWindow())
+	}
+
+	if _, exists := w.timeoutFor(snapshot.State); exists && !snapshot.TimeoutDeadline.IsZero() {
+		instance.startTimeout(snapshot.TimeoutDeadline)
+	}
+
+	return instance
+}
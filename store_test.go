@@ -0,0 +1,36 @@
+package ekstatic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+
+	_, err := store.Load("a")
+	require.ErrorIs(t, err, ErrInstanceNotFound)
+
+	require.NoError(t, store.Save("a", Snapshot{State: "saved"}))
+
+	snapshot, err := store.Load("a")
+	require.NoError(t, err)
+	require.Equal(t, "saved", snapshot.State)
+}
+
+func TestWorkflowInstance_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(state string, input string) string { return state + input })
+
+	instance := w.New("Hello")
+	require.NoError(t, instance.ContinueWith(", World!"))
+
+	restored := w.Restore("restored", instance.Snapshot())
+	require.Equal(t, "restored", restored.ID())
+	require.Equal(t, "Hello, World!", restored.CurrentState())
+}
@@ -0,0 +1,88 @@
+package ekstatic
+
+import (
+	"reflect"
+)
+
+// submachine is implemented by *WorkflowInstance and thereby by every state
+// that embeds one. Inputs the workflow has no transition for are forwarded
+// to the submachine of the current state.
+type submachine interface {
+	forward(input ...any) error
+	canForward(input ...any) bool
+	completion() (finalState any, isFinal bool)
+}
+
+// AddFinalStates marks the types of the given states as final. When the
+// submachine of a state reaches a final state, the parent instance applies
+// that final state as input to its current state, if it has a transition
+// for it.
+func (w *Workflow) AddFinalStates(states ...any) {
+	w.lock()
+	defer w.mu.Unlock()
+
+	if w.finalStates == nil {
+		w.finalStates = make(map[string]struct{})
+	}
+
+	for _, state := range states {
+		if state == nil {
+			panic("final state must not be nil")
+		}
+
+		w.finalStates[reflect.TypeOf(state).String()] = struct{}{}
+	}
+}
+
+func (w *Workflow) isFinal(state any) bool {
+	defer w.rlock()()
+
+	_, isFinal := w.finalStates[reflect.TypeOf(state).String()]
+	return isFinal
+}
+
+// IsFinal reports whether the instance is in a final state.
+func (w *WorkflowInstance) IsFinal() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.workflow.isFinal(w.currentState)
+}
+
+func (w *WorkflowInstance) forward(input ...any) error {
+	if w == nil {
+		return ErrTransitionDoesNotExist
+	}
+
+	return w.ContinueWith(input...)
+}
+
+func (w *WorkflowInstance) completion() (any, bool) {
+	if w == nil {
+		return nil, false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.currentState, w.workflow.isFinal(w.currentState)
+}
+
+// forwardTo applies the input to the submachine of the current state and,
+// once the submachine is final, its final state to the instance itself.
+func (w *WorkflowInstance) forwardTo(sub submachine, input ...any) error {
+	if err := sub.forward(input...); err != nil {
+		return err
+	}
+
+	finalState, isFinal := sub.completion()
+	if !isFinal {
+		return nil
+	}
+
+	if _, _, exists := w.workflow.resolveTransition(w.currentState, finalState); !exists {
+		return nil
+	}
+
+	return w.continueWith(finalState)
+}
@@ -0,0 +1,142 @@
+package ekstatic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateChildStarted  string
+	stateChildFinished string
+
+	stateParentWaiting struct {
+		*WorkflowInstance
+	}
+	stateParentEmbeddingIndirectly struct {
+		stateParentWaiting
+	}
+	stateParentDone string
+
+	inputChildStep   string
+	inputChildFinish struct{}
+	inputChildFail   struct{}
+)
+
+func newChildWorkflow() *Workflow {
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(s stateChildStarted, i inputChildStep) stateChildStarted { return s + stateChildStarted(i) },
+		func(s stateChildStarted, i inputChildFinish) stateChildFinished { return stateChildFinished(s) },
+		func(stateChildStarted, inputChildFail) (stateChildStarted, error) { return "", errors.New("child failed") },
+	)
+	w.AddFinalStates(stateChildFinished(""))
+
+	return w
+}
+
+func TestWorkflow_AddFinalStates(t *testing.T) {
+	t.Parallel()
+
+	w := newChildWorkflow()
+	require.PanicsWithValue(t, "final state must not be nil", func() { w.AddFinalStates(nil) })
+
+	instance := w.New(stateChildStarted(""))
+	require.False(t, instance.IsFinal())
+	require.NoError(t, instance.ContinueWith(inputChildFinish{}))
+	require.True(t, instance.IsFinal())
+}
+
+func TestWorkflowInstance_ContinueWith_submachine(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		parentState      func(child *WorkflowInstance) any
+		inputs           []any
+		err              error
+		childState       any
+		destinationState any
+	}{
+		{
+			name:             "state is submachine",
+			parentState:      func(child *WorkflowInstance) any { return child },
+			inputs:           []any{inputChildStep("a"), inputChildStep("b")},
+			childState:       stateChildStarted("ab"),
+			destinationState: nil,
+		},
+		{
+			name:             "state embeds submachine",
+			parentState:      func(child *WorkflowInstance) any { return stateParentWaiting{child} },
+			inputs:           []any{inputChildStep("a"), inputChildStep("b"), inputChildFinish{}},
+			childState:       stateChildFinished("ab"),
+			destinationState: stateParentDone("ab"),
+		},
+		{
+			name: "state embeds submachine indirectly",
+			parentState: func(child *WorkflowInstance) any {
+				return stateParentEmbeddingIndirectly{stateParentWaiting{child}}
+			},
+			inputs:           []any{inputChildStep("a"), inputChildFinish{}},
+			childState:       stateChildFinished("a"),
+			destinationState: stateParentDone("indirectly a"),
+		},
+		{
+			name:        "submachine fails",
+			parentState: func(child *WorkflowInstance) any { return stateParentWaiting{child} },
+			inputs:      []any{inputChildFail{}},
+			err:         errors.New("child failed"),
+			childState:  stateChildStarted(""),
+		},
+		{
+			name:        "neither parent nor submachine has a transition",
+			parentState: func(child *WorkflowInstance) any { return stateParentWaiting{child} },
+			inputs:      []any{"unknown"},
+			err:         ErrTransitionDoesNotExist,
+			childState:  stateChildStarted(""),
+		},
+		{
+			name:        "embedded submachine is nil",
+			parentState: func(child *WorkflowInstance) any { return stateParentWaiting{} },
+			inputs:      []any{inputChildStep("a")},
+			err:         ErrTransitionDoesNotExist,
+			childState:  stateChildStarted(""),
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			parentWorkflow := NewWorkflow()
+			parentWorkflow.AddTransitions(
+				func(s stateParentWaiting, i stateChildFinished) stateParentDone { return stateParentDone(i) },
+				func(s stateParentEmbeddingIndirectly, i stateChildFinished) stateParentDone {
+					return stateParentDone("indirectly " + i)
+				},
+			)
+
+			child := newChildWorkflow().New(stateChildStarted(""))
+			parentState := tt.parentState(child)
+			parent := parentWorkflow.New(parentState)
+
+			var err error
+			for _, input := range tt.inputs {
+				if err = parent.ContinueWith(input); err != nil {
+					break
+				}
+			}
+
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.childState, child.CurrentState())
+
+			if tt.destinationState == nil {
+				require.Equal(t, parentState, parent.CurrentState())
+				return
+			}
+			require.Equal(t, tt.destinationState, parent.CurrentState())
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package ekstatic
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+)
+
+var ErrUnexpectedTargetState = errors.New("transition returned a state of a type not declared as its target")
+
+// Targets declares the types of the states a transition returning an
+// interface type can return. Returning a state of another type makes the
+// transition fail with ErrUnexpectedTargetState. The declared targets are
+// reported by Transitions and shown in diagrams.
+func Targets(states ...any) TransitionOption {
+	targets := make([]reflect.Type, len(states))
+	for i, state := range states {
+		if state == nil {
+			panic("target state must not be nil")
+		}
+		targets[i] = reflect.TypeOf(state)
+	}
+
+	return func(c *transitionConfig) {
+		c.targets = targets
+	}
+}
+
+func (w *Workflow) isTarget(identifier transitionIdentifer, state any) bool {
+	config, exists := w.transitionConfig(identifier)
+	if !exists || config.targets == nil {
+		return true
+	}
+
+	return slices.Contains(config.targets, reflect.TypeOf(state))
+}
@@ -0,0 +1,74 @@
+package ekstatic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargets(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithValue(t, "target state must not be nil", func() { Targets(stateCharged{}, nil) })
+}
+
+func TestWorkflowInstance_ContinueWith_targets(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		result           any
+		err              error
+		destinationState any
+	}{
+		{
+			name:             "declared target",
+			result:           stateDeclined{},
+			destinationState: stateDeclined{},
+		},
+		{
+			name:             "undeclared target",
+			result:           "foo",
+			err:              ErrUnexpectedTargetState,
+			destinationState: stateCharging{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			emitted := false
+
+			w := NewWorkflow()
+			w.AddTransition(
+				func(s stateCharging, i inputCharge, e Emitter) any {
+					e.Emit(true)
+					return tt.result
+				},
+				Targets(stateCharged{}, stateDeclined{}),
+			)
+			w.AddTransition(func(s stateDeclined, b bool) stateDeclined {
+				emitted = true
+				return s
+			})
+
+			instance := w.New(stateCharging{})
+			require.Equal(t, tt.err, instance.ContinueWith(inputCharge(1)))
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+			require.Equal(t, tt.err == nil, emitted)
+		})
+	}
+}
+
+func TestWorkflow_Transitions_targets(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransition(func(s stateCharging, i inputCharge) any { return s }, Targets(stateCharged{}, stateDeclined{}))
+
+	descriptors := w.Transitions()
+	require.Equal(t, []reflect.Type{reflect.TypeFor[stateCharged](), reflect.TypeFor[stateDeclined]()}, descriptors[0].Results)
+}
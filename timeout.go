@@ -0,0 +1,106 @@
+package ekstatic
+
+import (
+	"errors"
+	"reflect"
+	"time"
+)
+
+var ErrTimeoutAlreadyExists = errors.New("there already is a timeout for that state type")
+
+type (
+	timeout struct {
+		duration time.Duration
+		input    func() any
+	}
+
+	activeTimeout struct {
+		deadline time.Time
+		timer    Timer
+	}
+)
+
+// AddTimeout makes instances apply the input created by the given function
+// if they are still in a state of the same type as state after duration.
+// The timeout is started when an instance enters a state of that type and
+// cancelled when it leaves it. Transitions to another state of the same type
+// don't restart it.
+func (w *Workflow) AddTimeout(state any, duration time.Duration, input func() any) {
+	if state == nil {
+		panic("timeout state must not be nil")
+	}
+
+	if input == nil {
+		panic("timeout input must not be nil")
+	}
+
+	w.lock()
+	defer w.mu.Unlock()
+
+	if w.timeouts == nil {
+		w.timeouts = make(map[string]timeout)
+	}
+
+	stateType := reflect.TypeOf(state).String()
+	if _, timeoutExists := w.timeouts[stateType]; timeoutExists {
+		panic(ErrTimeoutAlreadyExists)
+	}
+
+	w.timeouts[stateType] = timeout{duration, input}
+}
+
+func (w *Workflow) timeoutFor(state any) (timeout, bool) {
+	defer w.rlock()()
+
+	t, exists := w.timeouts[reflect.TypeOf(state).String()]
+	return t, exists
+}
+
+// updateTimeout starts or cancels the timeout of the instance after its
+// state has changed from previousState.
+func (w *WorkflowInstance) updateTimeout(previousState any) {
+	if reflect.TypeOf(previousState) == reflect.TypeOf(w.currentState) {
+		return
+	}
+
+	w.stopTimeout()
+
+	if t, exists := w.workflow.timeoutFor(w.currentState); exists {
+		w.startTimeout(w.workflow.getClock().Now().Add(t.duration))
+	}
+}
+
+func (w *WorkflowInstance) startTimeout(deadline time.Time) {
+	clock := w.workflow.getClock()
+
+	active := &activeTimeout{deadline: deadline}
+	active.timer = clock.AfterFunc(deadline.Sub(clock.Now()), func() { w.fireTimeout(active) })
+
+	w.timeout = active
+}
+
+func (w *WorkflowInstance) stopTimeout() {
+	if w.timeout == nil {
+		return
+	}
+
+	w.timeout.timer.Stop()
+	w.timeout = nil
+}
+
+func (w *WorkflowInstance) fireTimeout(active *activeTimeout) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.evicted || w.timeout != active {
+		return
+	}
+
+	t, exists := w.workflow.timeoutFor(w.currentState)
+	if !exists {
+		return
+	}
+
+	w.timeout = nil
+	_ = w.withHistoryErrors(w.continueWithKey(t.input()))
+}
@@ -0,0 +1,149 @@
+package ekstatic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateIdle    struct{}
+	stateRinging struct{ rings int }
+	stateMissed  struct{}
+	stateTalking struct{}
+
+	inputCall    struct{}
+	inputRing    struct{}
+	inputAnswer  struct{}
+	inputTimeout struct{}
+)
+
+func newPhoneWorkflow(clock Clock) *Workflow {
+	w := NewWorkflow()
+	w.SetClock(clock)
+	w.AddTransitions(
+		func(stateIdle, inputCall) stateRinging { return stateRinging{} },
+		func(s stateRinging, i inputRing) stateRinging { return stateRinging{s.rings + 1} },
+		func(stateRinging, inputAnswer) stateTalking { return stateTalking{} },
+		func(stateRinging, inputTimeout) stateMissed { return stateMissed{} },
+	)
+	w.AddTimeout(stateRinging{}, 30*time.Second, func() any { return inputTimeout{} })
+
+	return w
+}
+
+func TestWorkflow_AddTimeout(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+
+	require.PanicsWithValue(t, "timeout state must not be nil", func() {
+		w.AddTimeout(nil, time.Second, func() any { return inputTimeout{} })
+	})
+	require.PanicsWithValue(t, "timeout input must not be nil", func() {
+		w.AddTimeout(stateRinging{}, time.Second, nil)
+	})
+
+	w.AddTimeout(stateRinging{}, time.Second, func() any { return inputTimeout{} })
+	require.PanicsWithError(t, ErrTimeoutAlreadyExists.Error(), func() {
+		w.AddTimeout(stateRinging{}, time.Minute, func() any { return inputTimeout{} })
+	})
+}
+
+func TestWorkflowInstance_timeout(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		initialState     any
+		inputs           []any
+		advance          []time.Duration
+		destinationState any
+	}{
+		{
+			name:             "timeout fires after entering state",
+			initialState:     stateIdle{},
+			inputs:           []any{inputCall{}},
+			advance:          []time.Duration{30 * time.Second},
+			destinationState: stateMissed{},
+		},
+		{
+			name:             "timeout doesn't fire early",
+			initialState:     stateIdle{},
+			inputs:           []any{inputCall{}},
+			advance:          []time.Duration{29 * time.Second},
+			destinationState: stateRinging{},
+		},
+		{
+			name:             "timeout is cancelled when leaving state",
+			initialState:     stateIdle{},
+			inputs:           []any{inputCall{}, inputAnswer{}},
+			advance:          []time.Duration{time.Minute},
+			destinationState: stateTalking{},
+		},
+		{
+			name:             "timeout isn't restarted by transitions to the same state type",
+			initialState:     stateIdle{},
+			inputs:           []any{inputCall{}, inputRing{}},
+			advance:          []time.Duration{20 * time.Second, 10 * time.Second},
+			destinationState: stateMissed{},
+		},
+		{
+			name:             "timeout of initial state",
+			initialState:     stateRinging{},
+			advance:          []time.Duration{30 * time.Second},
+			destinationState: stateMissed{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt := tt
+			t.Parallel()
+
+			clock := NewManualClock(testEpoch)
+			instance := newPhoneWorkflow(clock).New(tt.initialState)
+
+			for _, input := range tt.inputs {
+				require.NoError(t, instance.ContinueWith(input))
+			}
+
+			for _, d := range tt.advance {
+				clock.Advance(d)
+			}
+
+			require.Equal(t, tt.destinationState, instance.CurrentState())
+		})
+	}
+}
+
+func TestWorkflowInstance_timeout_restored(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(testEpoch)
+	store := NewMemoryStore()
+	m := NewManager(newPhoneWorkflow(clock), store)
+
+	_, err := m.New("call", stateIdle{})
+	require.NoError(t, err)
+	require.NoError(t, m.Dispatch("call", inputCall{}))
+
+	clock.Advance(20 * time.Second)
+	require.NoError(t, m.Evict("call"))
+
+	snapshot, err := store.Load("call")
+	require.NoError(t, err)
+	require.Equal(t, testEpoch.Add(30*time.Second), snapshot.TimeoutDeadline)
+
+	// The timer of the evicted instance must not fire anymore.
+	clock.Advance(5 * time.Second)
+
+	instance, err := m.Get("call")
+	require.NoError(t, err)
+	require.Equal(t, stateRinging{}, instance.CurrentState())
+
+	clock.Advance(5 * time.Second)
+	require.Equal(t, stateMissed{}, instance.CurrentState())
+	require.True(t, instance.Snapshot().TimeoutDeadline.IsZero())
+}
@@ -0,0 +1,321 @@
+package ekstatic
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var ErrWorkflowInvalid = errors.New("workflow is invalid")
+
+// ValidationReport lists the problems Validate found in a workflow. All
+// types are sorted by name.
+type ValidationReport struct {
+	// UnreachableStates are state types transitions are registered for
+	// that can't be reached from the initial state.
+	UnreachableStates []reflect.Type
+
+	// DeadEnds are reachable state types that aren't final and have no
+	// transitions, neither their own nor inherited ones.
+	DeadEnds []reflect.Type
+
+	// UnusedInputs are input types only accepted by unreachable states.
+	UnusedInputs []reflect.Type
+
+	// EpsilonCycles are cycles of ε-transitions, each starting with the
+	// state type of the smallest name.
+	EpsilonCycles [][]reflect.Type
+
+	// OrphanedTransitions are transitions for a state type that neither
+	// the initial state nor any transition or failure edge produces.
+	OrphanedTransitions []TransitionDescriptor
+}
+
+// Validate analyses the structure of the workflow, assuming instances start
+// in a state of the type of initialState. Transitions returning an interface
+// type without declared Targets are assumed to lead nowhere.
+func (w *Workflow) Validate(initialState any) ValidationReport {
+	if initialState == nil {
+		panic("initial state must not be nil")
+	}
+
+	var (
+		report      ValidationReport
+		descriptors = w.Transitions()
+		edges       = w.failureEdgeTypes()
+		initial     = reflect.TypeOf(initialState)
+	)
+
+	// Collect transitions by state type
+
+	outgoing := make(map[reflect.Type][]TransitionDescriptor)
+	for _, descriptor := range descriptors {
+		outgoing[descriptor.State] = append(outgoing[descriptor.State], descriptor)
+	}
+
+	inherited := func(state reflect.Type) []TransitionDescriptor {
+		var transitions []TransitionDescriptor
+		for _, t := range append([]reflect.Type{state}, ancestorTypes(state)...) {
+			for _, descriptor := range outgoing[t] {
+				if t == state || !descriptor.Epsilon {
+					transitions = append(transitions, descriptor)
+				}
+			}
+		}
+		return transitions
+	}
+
+	// Find reachable states
+
+	reachable := map[reflect.Type]struct{}{initial: {}}
+	queue := []reflect.Type{initial}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		var next []reflect.Type
+		for _, descriptor := range inherited(state) {
+			next = append(next, resultTypes(state, descriptor)...)
+		}
+		for _, t := range append([]reflect.Type{state}, ancestorTypes(state)...) {
+			next = append(next, edges[t]...)
+		}
+
+		for _, t := range next {
+			if _, exists := reachable[t]; !exists {
+				reachable[t] = struct{}{}
+				queue = append(queue, t)
+			}
+		}
+	}
+
+	isReachable := func(state reflect.Type) bool {
+		if _, exists := reachable[state]; exists {
+			return true
+		}
+		for t := range reachable {
+			if slices.Contains(ancestorTypes(t), state) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Report unreachable states and unused inputs
+
+	acceptedInputs := make(map[reflect.Type]bool)
+	for state, transitions := range outgoing {
+		stateReachable := isReachable(state)
+		if !stateReachable {
+			report.UnreachableStates = append(report.UnreachableStates, state)
+		}
+
+		for _, descriptor := range transitions {
+			for _, input := range descriptor.Inputs {
+				acceptedInputs[input] = acceptedInputs[input] || stateReachable
+			}
+		}
+	}
+
+	for input, accepted := range acceptedInputs {
+		if !accepted {
+			report.UnusedInputs = append(report.UnusedInputs, input)
+		}
+	}
+
+	// Report dead ends
+
+	for state := range reachable {
+		if state.Kind() == reflect.Interface || len(inherited(state)) > 0 {
+			continue
+		}
+		if w.isFinal(reflect.New(state).Elem().Interface()) {
+			continue
+		}
+		report.DeadEnds = append(report.DeadEnds, state)
+	}
+
+	// Report ε-cycles
+
+	report.EpsilonCycles = epsilonCycles(outgoing)
+
+	// Report orphaned transitions
+
+	produced := map[reflect.Type]struct{}{initial: {}}
+	for _, descriptor := range descriptors {
+		for _, t := range resultTypes(descriptor.State, descriptor) {
+			produced[t] = struct{}{}
+		}
+	}
+	for _, targets := range edges {
+		for _, t := range targets {
+			produced[t] = struct{}{}
+		}
+	}
+	for t := range produced {
+		for _, ancestor := range ancestorTypes(t) {
+			produced[ancestor] = struct{}{}
+		}
+	}
+
+	for _, descriptor := range descriptors {
+		if _, exists := produced[descriptor.State]; !exists {
+			report.OrphanedTransitions = append(report.OrphanedTransitions, descriptor)
+		}
+	}
+
+	sortTypes(report.UnreachableStates)
+	sortTypes(report.DeadEnds)
+	sortTypes(report.UnusedInputs)
+
+	return report
+}
+
+// Err returns nil if the report lists no problems, and an error wrapping
+// ErrWorkflowInvalid that describes them otherwise.
+func (r ValidationReport) Err() error {
+	var problems []string
+
+	if len(r.UnreachableStates) > 0 {
+		problems = append(problems, "unreachable states "+typeNames(r.UnreachableStates))
+	}
+	if len(r.DeadEnds) > 0 {
+		problems = append(problems, "dead ends "+typeNames(r.DeadEnds))
+	}
+	if len(r.UnusedInputs) > 0 {
+		problems = append(problems, "unused inputs "+typeNames(r.UnusedInputs))
+	}
+	for _, cycle := range r.EpsilonCycles {
+		problems = append(problems, "ε-cycle "+typeNames(cycle))
+	}
+	for _, descriptor := range r.OrphanedTransitions {
+		problems = append(problems, "orphaned transition "+descriptor.Name)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrWorkflowInvalid, strings.Join(problems, "; "))
+}
+
+func (w *Workflow) failureEdgeTypes() map[reflect.Type][]reflect.Type {
+	defer w.rlock()()
+
+	edges := make(map[reflect.Type][]reflect.Type)
+	for _, stateEdges := range w.failureEdges {
+		for _, edge := range stateEdges {
+			from := reflect.TypeOf(edge.from)
+			edges[from] = append(edges[from], reflect.TypeOf(edge.to))
+		}
+	}
+
+	return edges
+}
+
+// resultTypes returns the types of the states a transition from a state of
+// the given type leads to.
+func resultTypes(state reflect.Type, descriptor TransitionDescriptor) []reflect.Type {
+	if descriptor.Result == reflect.TypeFor[Fork]() {
+		return []reflect.Type{state}
+	}
+
+	return slices.DeleteFunc(slices.Clone(descriptor.Results), func(t reflect.Type) bool {
+		return t == reflect.TypeFor[HistoryState]()
+	})
+}
+
+// ancestorTypes returns the types of the parent states of the zero value of
+// the state type.
+func ancestorTypes(state reflect.Type) (parents []reflect.Type) {
+	if state.Kind() == reflect.Interface || !state.Implements(reflect.TypeFor[Substate]()) {
+		return nil
+	}
+
+	defer func() {
+		if recover() != nil {
+			parents = nil
+		}
+	}()
+
+	for _, parent := range ancestors(reflect.New(state).Elem().Interface()) {
+		parents = append(parents, reflect.TypeOf(parent))
+	}
+
+	return parents
+}
+
+func epsilonCycles(outgoing map[reflect.Type][]TransitionDescriptor) [][]reflect.Type {
+	var (
+		cycles  [][]reflect.Type
+		seen    = make(map[string]struct{})
+		path    []reflect.Type
+		visited = make(map[reflect.Type]bool)
+		visit   func(state reflect.Type)
+	)
+
+	visit = func(state reflect.Type) {
+		if i := slices.Index(path, state); i != -1 {
+			cycle := slices.Clone(path[i:])
+			start := 0
+			for j, t := range cycle {
+				if t.String() < cycle[start].String() {
+					start = j
+				}
+			}
+			cycle = append(cycle[start:], cycle[:start]...)
+
+			if _, exists := seen[typeNames(cycle)]; !exists {
+				seen[typeNames(cycle)] = struct{}{}
+				cycles = append(cycles, cycle)
+			}
+			return
+		}
+
+		if visited[state] {
+			return
+		}
+
+		path = append(path, state)
+		for _, descriptor := range outgoing[state] {
+			if descriptor.Epsilon && descriptor.Result != reflect.TypeFor[Fork]() {
+				for _, next := range resultTypes(state, descriptor) {
+					visit(next)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+
+		visited[state] = true
+	}
+
+	states := make([]reflect.Type, 0, len(outgoing))
+	for state := range outgoing {
+		states = append(states, state)
+	}
+	sortTypes(states)
+
+	for _, state := range states {
+		visit(state)
+	}
+
+	return cycles
+}
+
+func sortTypes(types []reflect.Type) {
+	slices.SortFunc(types, func(a, b reflect.Type) int {
+		return strings.Compare(a.String(), b.String())
+	})
+}
+
+func typeNames(types []reflect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,93 @@
+package ekstatic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	stateValidationStart    struct{}
+	stateValidationLoopA    struct{}
+	stateValidationLoopB    struct{}
+	stateValidationStuck    struct{}
+	stateValidationFailed   struct{}
+	stateValidationDone     struct{}
+	stateValidationIsolated struct{}
+	stateValidationChild    struct{}
+	stateValidationParent   struct{}
+
+	inputValidationGo     struct{}
+	inputValidationUnused struct{}
+)
+
+func (stateValidationChild) ParentState() any { return stateValidationParent{} }
+
+func TestWorkflow_Validate(t *testing.T) {
+	t.Parallel()
+
+	w := NewWorkflow()
+	w.AddTransitions(
+		func(stateValidationStart, inputValidationGo) (stateValidationStuck, error) {
+			return stateValidationStuck{}, nil
+		},
+		func(stateValidationStart, inputCharge) any { return stateValidationChild{} },
+		func(stateValidationLoopA) stateValidationLoopB { return stateValidationLoopB{} },
+		func(stateValidationLoopB) stateValidationLoopA { return stateValidationLoopA{} },
+		func(stateValidationParent, inputValidationGo) stateValidationDone { return stateValidationDone{} },
+		func(stateValidationIsolated, inputValidationUnused) stateValidationDone { return stateValidationDone{} },
+	)
+	w.AddFailureEdges(OnError[error](stateValidationStart{}, stateValidationFailed{}))
+	w.AddFinalStates(stateValidationDone{}, stateValidationFailed{})
+
+	report := w.Validate(stateValidationStart{})
+	require.Equal(t, []reflect.Type{
+		reflect.TypeFor[stateValidationIsolated](),
+		reflect.TypeFor[stateValidationLoopA](),
+		reflect.TypeFor[stateValidationLoopB](),
+		reflect.TypeFor[stateValidationParent](),
+	}, report.UnreachableStates)
+	require.Equal(t, []reflect.Type{reflect.TypeFor[stateValidationStuck]()}, report.DeadEnds)
+	require.Equal(t, []reflect.Type{reflect.TypeFor[inputValidationUnused]()}, report.UnusedInputs)
+	require.Equal(t, [][]reflect.Type{
+		{reflect.TypeFor[stateValidationLoopA](), reflect.TypeFor[stateValidationLoopB]()},
+	}, report.EpsilonCycles)
+	require.Len(t, report.OrphanedTransitions, 2)
+	require.Equal(t, reflect.TypeFor[stateValidationIsolated](), report.OrphanedTransitions[0].State)
+	require.Equal(t, reflect.TypeFor[stateValidationParent](), report.OrphanedTransitions[1].State)
+
+	require.ErrorIs(t, report.Err(), ErrWorkflowInvalid)
+
+	w.ReplaceTransition(
+		func(stateValidationStart, inputCharge) any { return stateValidationChild{} },
+		Targets(stateValidationChild{}),
+	)
+	w.RemoveTransition(stateValidationIsolated{}, inputValidationUnused{})
+	w.RemoveTransition(stateValidationLoopA{})
+	w.RemoveTransition(stateValidationLoopB{})
+	w.AddTransition(func(stateValidationStuck) stateValidationDone { return stateValidationDone{} })
+
+	report = w.Validate(stateValidationStart{})
+	require.Equal(t, ValidationReport{}, report)
+	require.NoError(t, report.Err())
+
+	require.PanicsWithValue(t, "initial state must not be nil", func() { w.Validate(nil) })
+}
+
+func TestValidationReport_Err(t *testing.T) {
+	t.Parallel()
+
+	report := ValidationReport{
+		UnreachableStates: []reflect.Type{reflect.TypeFor[string](), reflect.TypeFor[int]()},
+		DeadEnds:          []reflect.Type{reflect.TypeFor[bool]()},
+		UnusedInputs:      []reflect.Type{reflect.TypeFor[float64]()},
+		EpsilonCycles:     [][]reflect.Type{{reflect.TypeFor[int](), reflect.TypeFor[string]()}},
+		OrphanedTransitions: []TransitionDescriptor{
+			{Name: "pkg.transition"},
+		},
+	}
+
+	require.EqualError(t, report.Err(), "workflow is invalid: unreachable states string, int; dead ends bool; "+
+		"unused inputs float64; ε-cycle int, string; orphaned transition pkg.transition")
+}